@@ -0,0 +1,61 @@
+package ccxxd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// annotation names a byte range of the input, used by --annotate
+type annotation struct {
+	name   string
+	start  int64
+	length int64
+}
+
+// parseAnnotations parses a spec like "0:4=magic,4:2=version" into annotations.
+// Each field is <start>:<len>=<name>, start and len in bytes.
+func parseAnnotations(spec string) ([]annotation, error) {
+	var res []annotation
+
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		rng, name, ok := strings.Cut(field, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("annotation %q is missing a name, want <start>:<len>=<name>", field)
+		}
+		startStr, lenStr, ok := strings.Cut(rng, ":")
+		if !ok {
+			return nil, fmt.Errorf("annotation %q is missing a length, want <start>:<len>=<name>", field)
+		}
+		start, err := strconv.ParseInt(startStr, 0, 64)
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid start in annotation %q", field)
+		}
+		length, err := strconv.ParseInt(lenStr, 0, 64)
+		if err != nil || length <= 0 {
+			return nil, fmt.Errorf("invalid length in annotation %q", field)
+		}
+		res = append(res, annotation{name: name, start: start, length: length})
+	}
+	return res, nil
+}
+
+// printAnnotations prints the names of all annotated fields overlapping the line
+// starting at offset, as a comment line below the dumped line, which -r skips.
+func (cmd *command) printAnnotations(offset int64, lineLength int) {
+	var names []string
+	end := offset + int64(lineLength)
+
+	for _, a := range cmd.annotations {
+		if a.start < end && a.start+a.length > offset {
+			names = append(names, a.name)
+		}
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(cmd.output, "# %s\n", strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,44 @@
+package ccxxd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnnotate(t *testing.T) {
+	annotations, err := parseAnnotations("0:4=magic, 6:4=length")
+	assertNoError(t, err)
+
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("RIFF\x00\x00\x10\x00\x00\x00data"),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		annotations:  annotations,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `00000000: 5249 4646 0000 1000  RIFF....
+# magic, length
+00000008: 0000 6461 7461       ..data
+# length
+`
+	assertEqual(t, out.String(), want)
+
+	// -r skips the annotation lines
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(out.String()), &reverted, revertOptions{}))
+	assertEqual(t, reverted.String(), "RIFF\x00\x00\x10\x00\x00\x00data")
+}
+
+func TestParseAnnotationsInvalid(t *testing.T) {
+	for _, spec := range []string{"0:4", "0=magic", "x:4=magic", "0:0=empty", "-1:2=neg"} {
+		_, err := parseAnnotations(spec)
+		if err == nil {
+			t.Errorf("expected error for spec %q", spec)
+		}
+	}
+}
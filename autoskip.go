@@ -0,0 +1,107 @@
+package ccxxd
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// autoskipper collapses runs of full all-zero lines into a single "*" line for -a,
+// following xxd: the first line of a run is always printed, and so is the last
+// one if the input ends with the run. --skip-after prints more lines of the run
+// before the marker, --skip-marker replaces the "*".
+// With --squeeze a run is any number of identical lines, not just zero ones.
+// After --max-skips markers every line is printed.
+// --od squeezes the way od does instead: even a single repeat becomes a "*"
+// and the run's last line isn't printed, the offset footer follows the marker.
+type autoskipper struct {
+	cmd      *command
+	after    int    // Lines of a run printed before it is collapsed, 1 as in xxd
+	marker   string // Line standing in for the skipped lines
+	maxSkips int    // Markers to print before collapsing stops, 0 for no limit
+	skips    int    // Markers printed so far
+	squeeze  bool   // Every line starts a run of its repeats, -a only has runs of zeros
+	od       bool   // Collapse runs as od does, see above
+	pattern  []byte // The line the current run repeats
+	run      int    // Number of consecutive lines equal to pattern seen
+	held     []byte // First line held back, printed instead of the marker when it is the only one skipped
+	heldAt   int64
+	last     []byte // Latest line of the run, printed if the input ends with it
+	lastAt   int64
+}
+
+func newAutoskipper(cmd *command) *autoskipper {
+	marker := cmd.skipMarker
+	if marker == "" {
+		marker = "*"
+	}
+	return &autoskipper{
+		cmd:      cmd,
+		after:    max(cmd.skipAfter, 1),
+		marker:   marker,
+		maxSkips: cmd.maxSkips,
+		squeeze:  cmd.squeeze,
+		od:       cmd.od,
+		pattern:  make([]byte, cmd.bytesPerLine),
+	}
+}
+
+// line prints the line or holds it back as part of a run of repeated lines.
+func (a *autoskipper) line(offset int64, line []byte) error {
+	if a.maxSkips > 0 && a.skips >= a.maxSkips {
+		// Out of collapses, the run state was reset by the flush that used the last one
+		return a.cmd.emitLine(offset, line)
+	}
+	if !bytes.Equal(line, a.pattern) {
+		err := a.flush(false)
+		if err != nil {
+			return err
+		}
+		a.run = 0
+		if !a.squeeze {
+			return a.cmd.emitLine(offset, line)
+		}
+		a.pattern = line
+	}
+
+	a.run++
+	switch {
+	case a.run <= a.after:
+		return a.cmd.emitLine(offset, line)
+	case a.run == a.after+1:
+		a.held, a.heldAt = line, offset
+	}
+	a.last, a.lastAt = line, offset
+	return nil
+}
+
+// flush prints what stands in for the held back lines of the current run,
+// atEnd is set once the input is exhausted.
+func (a *autoskipper) flush(atEnd bool) error {
+	if a.run <= a.after {
+		return nil
+	}
+	if a.od {
+		fmt.Fprintln(a.cmd.output, a.marker)
+		a.skips++
+		return nil
+	}
+	skipped := a.run - a.after
+	if atEnd {
+		// The final line of the input is printed, so it isn't skipped
+		skipped--
+	}
+	switch {
+	case skipped == 1:
+		err := a.cmd.emitLine(a.heldAt, a.held)
+		if err != nil {
+			return err
+		}
+	case skipped > 1:
+		fmt.Fprintln(a.cmd.output, a.marker)
+		a.skips++
+	}
+	if atEnd {
+		return a.cmd.emitLine(a.lastAt, a.last)
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package ccxxd
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAutoskip(t *testing.T) {
+	zeros := func(n int) string { return strings.Repeat("\x00", n) }
+	zeroLine := "0000 0000 0000 0000 0000 0000 0000 0000  ................\n"
+
+	// Expected output captured from: xxd -a
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "run between data collapses to a star",
+			input: "abc" + zeros(80) + "xyz",
+			want: "00000000: 6162 6300 0000 0000 0000 0000 0000 0000  abc.............\n" +
+				"00000010: " + zeroLine +
+				"*\n" +
+				"00000050: 0000 0078 797a                           ...xyz\n",
+		},
+		{
+			name:  "last zero line of the input is printed",
+			input: zeros(64),
+			want: "00000000: " + zeroLine +
+				"*\n" +
+				"00000030: " + zeroLine,
+		},
+		{
+			name:  "a single skipped line is printed instead of a star",
+			input: zeros(48),
+			want: "00000000: " + zeroLine +
+				"00000010: " + zeroLine +
+				"00000020: " + zeroLine,
+		},
+		{
+			name:  "non-zero line resets the run",
+			input: zeros(16) + "x" + zeros(47) + "y" + zeros(32),
+			want: "00000000: " + zeroLine +
+				"00000010: 7800 0000 0000 0000 0000 0000 0000 0000  x...............\n" +
+				"00000020: " + zeroLine +
+				"00000030: " + zeroLine +
+				"00000040: 7900 0000 0000 0000 0000 0000 0000 0000  y...............\n" +
+				"00000050: " + zeroLine +
+				"00000060: 00                                       .\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader(tt.input),
+				bytesPerLine: 16,
+				groupSize:    2,
+				maxBytes:     -1,
+				autoskip:     true,
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
+
+func TestAutoskipThreshold(t *testing.T) {
+	zeroLine := "0000 0000 0000 0000 0000 0000 0000 0000  ................\n"
+	dataLine := func(offset string, b byte) string {
+		return offset + ": " + fmt.Sprintf("%02x", b) + "00 0000 0000 0000 0000 0000 0000 0000  " + string(b) + "...............\n"
+	}
+	// A run of n zero lines between two data lines
+	run := func(n int) string {
+		return "a" + strings.Repeat("\x00", 15) + strings.Repeat("\x00", 16*n) + "z" + strings.Repeat("\x00", 15)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "run of exactly n lines is printed",
+			input: run(3),
+			want: dataLine("00000000", 'a') +
+				"00000010: " + zeroLine +
+				"00000020: " + zeroLine +
+				"00000030: " + zeroLine +
+				dataLine("00000040", 'z'),
+		},
+		{
+			name:  "run of n+1 lines prints the single skipped line",
+			input: run(4),
+			want: dataLine("00000000", 'a') +
+				"00000010: " + zeroLine +
+				"00000020: " + zeroLine +
+				"00000030: " + zeroLine +
+				"00000040: " + zeroLine +
+				dataLine("00000050", 'z'),
+		},
+		{
+			name:  "run of n+2 lines collapses",
+			input: run(5),
+			want: dataLine("00000000", 'a') +
+				"00000010: " + zeroLine +
+				"00000020: " + zeroLine +
+				"00000030: " + zeroLine +
+				"-- zeros --\n" +
+				dataLine("00000060", 'z'),
+		},
+		{
+			name:  "last line of the input is printed",
+			input: strings.Repeat("\x00", 16*6),
+			want: "00000000: " + zeroLine +
+				"00000010: " + zeroLine +
+				"00000020: " + zeroLine +
+				"-- zeros --\n" +
+				"00000050: " + zeroLine,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader(tt.input),
+				bytesPerLine: 16,
+				groupSize:    2,
+				maxBytes:     -1,
+				autoskip:     true,
+				skipAfter:    3,
+				skipMarker:   "-- zeros --",
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
+
+func TestMaxSkips(t *testing.T) {
+	zeroLine := "0000 0000 0000 0000 0000 0000 0000 0000  ................\n"
+	dataLine := func(offset string, b byte) string {
+		return offset + ": " + fmt.Sprintf("%02x", b) + "00 0000 0000 0000 0000 0000 0000 0000  " + string(b) + "...............\n"
+	}
+	// Three separate runs of three zero lines, each between data lines
+	block := func(b byte) string { return string(b) + strings.Repeat("\x00", 15+16*3) }
+	input := block('a') + block('b') + block('c') + "z" + strings.Repeat("\x00", 15)
+
+	tests := []struct {
+		name     string
+		maxSkips int
+		want     string
+	}{
+		{
+			name: "no limit",
+			want: dataLine("00000000", 'a') + "00000010: " + zeroLine + "*\n" +
+				dataLine("00000040", 'b') + "00000050: " + zeroLine + "*\n" +
+				dataLine("00000080", 'c') + "00000090: " + zeroLine + "*\n" +
+				dataLine("000000c0", 'z'),
+		},
+		{
+			name:     "everything is printed after the first collapse",
+			maxSkips: 1,
+			want: dataLine("00000000", 'a') + "00000010: " + zeroLine + "*\n" +
+				dataLine("00000040", 'b') + "00000050: " + zeroLine + "00000060: " + zeroLine + "00000070: " + zeroLine +
+				dataLine("00000080", 'c') + "00000090: " + zeroLine + "000000a0: " + zeroLine + "000000b0: " + zeroLine +
+				dataLine("000000c0", 'z'),
+		},
+		{
+			name:     "two collapses",
+			maxSkips: 2,
+			want: dataLine("00000000", 'a') + "00000010: " + zeroLine + "*\n" +
+				dataLine("00000040", 'b') + "00000050: " + zeroLine + "*\n" +
+				dataLine("00000080", 'c') + "00000090: " + zeroLine + "000000a0: " + zeroLine + "000000b0: " + zeroLine +
+				dataLine("000000c0", 'z'),
+		},
+	}
+
+	for _, tt := range tests {
+		for _, squeeze := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s squeeze=%v", tt.name, squeeze), func(t *testing.T) {
+				var out bytes.Buffer
+				cmd := command{
+					output:       &out,
+					input:        strings.NewReader(input),
+					bytesPerLine: 16,
+					groupSize:    2,
+					maxBytes:     -1,
+					autoskip:     !squeeze,
+					squeeze:      squeeze,
+					maxSkips:     tt.maxSkips,
+				}
+				assertNoError(t, cmd.run())
+				assertEqual(t, out.String(), tt.want)
+			})
+		}
+	}
+}
+
+func TestSqueeze(t *testing.T) {
+	ffLine := "ffff ffff ffff ffff ffff ffff ffff ffff  ................\n"
+	zeroLine := "0000 0000 0000 0000 0000 0000 0000 0000  ................\n"
+	abcLine := "6162 6364 6566 6768 696a 6b6c 6d6e 6f70  abcdefghijklmnop\n"
+	ff := func(n int) string { return strings.Repeat("\xff", n) }
+	zeros := func(n int) string { return strings.Repeat("\x00", n) }
+	abc := func(n int) string { return strings.Repeat("abcdefghijklmnop", n) }
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "run of 0xff collapses and the offset resumes after it",
+			input: "x" + ff(15) + ff(16*4) + "end",
+			want: "00000000: 78ff ffff ffff ffff ffff ffff ffff ffff  x...............\n" +
+				"00000010: " + ffLine +
+				"*\n" +
+				"00000050: 656e 64                                  end\n",
+		},
+		{
+			name:  "mixed runs each collapse",
+			input: ff(16*3) + zeros(16*3) + abc(4) + ff(16),
+			want: "00000000: " + ffLine +
+				"*\n" +
+				"00000030: " + zeroLine +
+				"*\n" +
+				"00000060: " + abcLine +
+				"*\n" +
+				"000000a0: " + ffLine,
+		},
+		{
+			name:  "a single repeat is printed instead of a star",
+			input: abc(2) + ff(16),
+			want: "00000000: " + abcLine +
+				"00000010: " + abcLine +
+				"00000020: " + ffLine,
+		},
+		{
+			name:  "short last line ends a run",
+			input: ff(16*3) + ff(4),
+			want: "00000000: " + ffLine +
+				"*\n" +
+				"00000030: ffff ffff                                ....\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader(tt.input),
+				bytesPerLine: 16,
+				groupSize:    2,
+				maxBytes:     -1,
+				squeeze:      true,
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
+
+func TestAutoskipRoundTrip(t *testing.T) {
+	// Zero runs in the middle and at the end, and a run of repeated 0xff lines
+	input := "head" + strings.Repeat("\x00", 16*5) + "mid" + strings.Repeat("\xff", 16*4) + "tail" + strings.Repeat("\x00", 16*3+5)
+
+	tests := []struct {
+		name string
+		cmd  command
+	}{
+		{name: "-a", cmd: command{autoskip: true}},
+		{name: "--squeeze", cmd: command{squeeze: true}},
+		{name: "--skip-marker", cmd: command{squeeze: true, skipAfter: 2, skipMarker: "-- skipped --"}},
+		{name: "--squeeze --xor-key", cmd: command{squeeze: true, xorKey: []byte{0x5a, 0xa5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dump bytes.Buffer
+			cmd := tt.cmd
+			cmd.output = &dump
+			cmd.input = strings.NewReader(input)
+			cmd.bytesPerLine = 16
+			cmd.groupSize = 2
+			cmd.maxBytes = -1
+			assertNoError(t, cmd.run())
+			if !strings.Contains(dump.String(), "\n"+cmp.Or(cmd.skipMarker, "*")+"\n") {
+				t.Fatalf("dump has no skip marker:\n%s", dump.String())
+			}
+
+			var reverted bytes.Buffer
+			assertNoError(t, revertToBinary(strings.NewReader(dump.String()), &reverted, cmd.revertOptions()))
+			if reverted.String() != input {
+				t.Errorf("round trip gave %q, want %q", reverted.String(), input)
+			}
+		})
+	}
+}
@@ -1,7 +1,7 @@
 //go:build integration
 // +build integration
 
-package main
+package ccxxd
 
 import (
 	"log"
@@ -27,7 +27,6 @@ func TestXxdIntegration(t *testing.T) {
 		}
 	})
 
-	// Failing bcs doesnt support right to left printing
 	t.Run("little endian -e", func(t *testing.T) {
 		for _, testFile := range testFiles {
 			cmd := exec.Command("./ccxxd", "-e", testFile)
@@ -166,6 +165,10 @@ func getTestFiles(testFolder string) []string {
 	}
 
 	for _, file := range files {
+		// Skip the golden files of TestXxdConformance
+		if file.IsDir() {
+			continue
+		}
 		res = append(res, testFolder+file.Name())
 	}
 	return res
@@ -0,0 +1,71 @@
+package ccxxd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// charsetFunc returns the character shown for byte b in the ascii panel,
+// ok is false if b is shown as the placeholder instead.
+type charsetFunc func(b byte) (r rune, ok bool)
+
+// charsetNames are the encodings --charset accepts, the first is the default.
+var charsetNames = []string{"ascii", "latin1", "cp1252", "ebcdic"}
+
+// charsets maps each of charsetNames to its panel function.
+var charsets = map[string]charsetFunc{
+	"ascii":  asciiChar,
+	"latin1": latin1Char,
+	"cp1252": cp1252Char,
+	"ebcdic": func(b byte) (rune, bool) {
+		c, ok := ebcdicChar(b)
+		return rune(c), ok
+	},
+}
+
+// cp1252Panel holds the Windows-1252 characters for bytes 0x80-0x9f, '.' where it has none.
+// The rest of the code page is the same as Latin-1.
+var cp1252Panel = []rune("€.‚ƒ„…†‡ˆ‰Š‹Œ.Ž." + // 0x80
+	".‘’“”•–—˜™š›œ.žŸ") // 0x90
+
+// asciiChar is the default panel, printable ascii only.
+func asciiChar(b byte) (rune, bool) {
+	return rune(b), isValidASCII(b)
+}
+
+// latin1Char adds the ISO 8859-1 characters 0xa0-0xff to the ascii ones.
+// The soft hyphen (0xad) is usually invisible, so it stays a placeholder to keep the panel aligned.
+func latin1Char(b byte) (rune, bool) {
+	return rune(b), isValidASCII(b) || b >= 0xa0 && b != 0xad
+}
+
+// cp1252Char is latin1Char plus the Windows-1252 punctuation and letters in 0x80-0x9f.
+func cp1252Char(b byte) (rune, bool) {
+	if b >= 0x80 && b < 0xa0 {
+		r := cp1252Panel[b-0x80]
+		return r, r != '.'
+	}
+	return latin1Char(b)
+}
+
+// parseGlyphs parses a --glyphs list like "0x09=→,0x0a=↵" into the character
+// shown in the ascii panel for each listed byte.
+func parseGlyphs(s string) (map[byte]rune, error) {
+	glyphs := map[byte]rune{}
+	for _, entry := range strings.Split(s, ",") {
+		byteStr, glyph, ok := strings.Cut(entry, "=")
+		value, err := strconv.ParseUint(byteStr, 0, 8)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid --glyphs entry %q, want <0xNN>=<char>", entry)
+		}
+		r, size := utf8.DecodeRuneInString(glyph)
+		if size == 0 || size != len(glyph) || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return nil, fmt.Errorf("invalid --glyphs entry %q, want a single printable character after =", entry)
+		}
+		glyphs[byte(value)] = r
+	}
+	return glyphs, nil
+}
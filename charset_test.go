@@ -0,0 +1,129 @@
+package ccxxd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCharset(t *testing.T) {
+	input := "caf\xe9 \x80\x93hi\x94\x81\xad\xff"
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "default panel is ascii",
+			args: []string{"-c", "8"},
+			want: "00000000: 6361 66e9 2080 9368  caf. ..h\n" +
+				"00000008: 6994 81ad ff         i....\n",
+		},
+		{
+			name: "latin1 decodes 0xa0-0xff except the soft hyphen",
+			args: []string{"-c", "8", "--charset", "latin1"},
+			want: "00000000: 6361 66e9 2080 9368  café ..h\n" +
+				"00000008: 6994 81ad ff         i...ÿ\n",
+		},
+		{
+			name: "cp1252 also decodes 0x80-0x9f",
+			args: []string{"-c", "8", "--charset", "cp1252"},
+			want: "00000000: 6361 66e9 2080 9368  café €“h\n" +
+				"00000008: 6994 81ad ff         i”..ÿ\n",
+		},
+		{
+			name: "placeholder still applies",
+			args: []string{"-c", "8", "--charset", "latin1", "-ph", "_"},
+			want: "00000000: 6361 66e9 2080 9368  café __h\n" +
+				"00000008: 6994 81ad ff         i___ÿ\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			code := runMain(tt.args, strings.NewReader(input), &out, &errOut)
+			if code != exitOK {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+			}
+			assertEqual(t, out.String(), tt.want)
+
+			// -r reads the hex field back, however wide the panel's UTF-8 is
+			var reverted bytes.Buffer
+			code = runMain([]string{"-r", "-c", "8"}, &out, &reverted, &errOut)
+			if code != exitOK {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+			}
+			assertEqual(t, reverted.String(), input)
+		})
+	}
+}
+
+func TestCharsetFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantStderr string
+	}{
+		{name: "unknown charset", args: []string{"--charset", "utf8"}, wantStderr: "invalid --charset \"utf8\""},
+		{name: "-E with another charset", args: []string{"-E", "--charset", "latin1"}, wantStderr: "-E and --charset latin1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			code := runMain(tt.args, strings.NewReader(""), &out, &errOut)
+			if code != exitUsage {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitUsage, errOut.String())
+			}
+			if !strings.Contains(errOut.String(), tt.wantStderr) {
+				t.Errorf("stderr %q does not contain %q", errOut.String(), tt.wantStderr)
+			}
+		})
+	}
+
+	// -E and --charset ebcdic agree
+	var out, errOut bytes.Buffer
+	code := runMain([]string{"-E", "--charset", "ebcdic"}, strings.NewReader("\xc1"), &out, &errOut)
+	if code != exitOK {
+		t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+	}
+	assertEqual(t, out.String(), "00000000: c1                                       A\n")
+}
+
+func TestGlyphs(t *testing.T) {
+	// Without --glyphs every byte keeps its default panel character
+	var plain command
+	for b := 0; b < 256; b++ {
+		c, ok := plain.panelChar(byte(b))
+		if ok != isValidASCII(byte(b)) || ok && c != rune(b) {
+			t.Errorf("panelChar(0x%02x) = %q, %v by default", b, c, ok)
+		}
+	}
+
+	glyphs, err := parseGlyphs("0x09=→,0x0a=↵,13=←")
+	assertNoError(t, err)
+
+	dump := func(glyphs map[byte]rune) string {
+		var out bytes.Buffer
+		cmd := command{
+			output:       &out,
+			input:        strings.NewReader("a\tb\r\nc\n\x00"),
+			bytesPerLine: 8,
+			groupSize:    2,
+			maxBytes:     -1,
+			glyphs:       glyphs,
+		}
+		assertNoError(t, cmd.run())
+		return out.String()
+	}
+	assertEqual(t, dump(nil), "00000000: 6109 620d 0a63 0a00  a.b..c..\n")
+	assertEqual(t, dump(glyphs), "00000000: 6109 620d 0a63 0a00  a→b←↵c↵.\n")
+
+	for _, spec := range []string{"", "0x09", "0x100=x", "9=ab", "9=", "9=\x01", "x=y"} {
+		if _, err := parseGlyphs(spec); err == nil {
+			t.Errorf("expected error for --glyphs %q", spec)
+		}
+	}
+}
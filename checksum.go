@@ -0,0 +1,73 @@
+package ccxxd
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// hashNames are the algorithms --hash accepts, "none" (the default) prints no digest.
+var hashNames = []string{"none", "md5", "sha1", "sha256"}
+
+// hashes makes a new hash.Hash for each of hashNames but "none".
+var hashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+}
+
+// hashConflicts are the flags --hash can't be combined with, since the "# <algo>:"
+// line would break their output or there is no single dump to end it.
+var hashConflicts = []string{
+	"r", "i", "p", "ps", "csv", "html", "j", "json", "od", "stats", "ranges", "compare-checksums", "split-output",
+}
+
+// printHash prints the "# <algo>: <hex>" line that ends a --hash dump.
+func (cmd *command) printHash() {
+	fmt.Fprintf(cmd.output, "# %s: %x\n", cmd.hashName, cmd.hash.Sum(nil))
+}
+
+// printChecksums prints "<sha256>  <file>" for each of cmd.checksumFiles,
+// hashing only the range selected by -s and -l.
+func (cmd *command) printChecksums() error {
+	for _, name := range cmd.checksumFiles {
+		sum, err := cmd.checksumFile(name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.output, "%x  %s\n", sum, name)
+	}
+	return nil
+}
+
+// checksumFile returns the sha256 digest of the dumped range of a single file.
+func (cmd *command) checksumFile(name string) ([]byte, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v as file: %v", name, err)
+	}
+	defer file.Close()
+
+	if cmd.startOffset > 0 {
+		_, err = file.Seek(cmd.startOffset, io.SeekStart)
+		if err != nil {
+			return nil, fmt.Errorf("error setting offset in %v: %v", name, err)
+		}
+	}
+
+	var reader io.Reader = file
+	if cmd.maxBytes >= 0 {
+		reader = io.LimitReader(file, cmd.maxBytes)
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %v: %v", name, err)
+	}
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,105 @@
+package ccxxd
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrintChecksums(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.bin")
+	second := filepath.Join(dir, "second.bin")
+	assertNoError(t, os.WriteFile(first, []byte("Hello, world!\n"), 0o644))
+	assertNoError(t, os.WriteFile(second, []byte("Goodbye, world!\n"), 0o644))
+
+	var out bytes.Buffer
+	cmd := command{
+		output:        &out,
+		maxBytes:      5,
+		startOffset:   2,
+		checksumFiles: []string{first, second},
+	}
+	assertNoError(t, cmd.printChecksums())
+
+	want := fmt.Sprintf("%x  %s\n%x  %s\n",
+		sha256.Sum256([]byte("llo, ")), first,
+		sha256.Sum256([]byte("odbye")), second)
+	assertEqual(t, out.String(), want)
+}
+
+func TestPrintChecksumsMissingFile(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:        &out,
+		maxBytes:      -1,
+		checksumFiles: []string{filepath.Join(t.TempDir(), "missing.bin")},
+	}
+	if err := cmd.printChecksums(); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
+
+func TestHash(t *testing.T) {
+	input := "The quick brown fox jumps over the lazy dog"
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "sha256", args: []string{"--hash", "sha256"}, want: fmt.Sprintf("# sha256: %x\n", sha256.Sum256([]byte(input)))},
+		{name: "sha1", args: []string{"--hash", "sha1"}, want: fmt.Sprintf("# sha1: %x\n", sha1.Sum([]byte(input)))},
+		{name: "md5", args: []string{"--hash", "md5"}, want: fmt.Sprintf("# md5: %x\n", md5.Sum([]byte(input)))},
+		{name: "only the -s/-l range", args: []string{"--hash", "sha256", "-s", "4", "-l", "5"}, want: fmt.Sprintf("# sha256: %x\n", sha256.Sum256([]byte("quick")))},
+		{name: "input bytes, not the xored ones", args: []string{"--hash", "sha256", "--xor-key", "20"}, want: fmt.Sprintf("# sha256: %x\n", sha256.Sum256([]byte(input)))},
+		{name: "empty range", args: []string{"--hash", "sha256", "-s", "100"}, want: fmt.Sprintf("# sha256: %x\n", sha256.Sum256(nil))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			code := runMain(tt.args, strings.NewReader(input), &out, &errOut)
+			if code != exitOK {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+			}
+			if !strings.HasSuffix(out.String(), tt.want) {
+				t.Errorf("dump doesn't end with %q:\n%s", tt.want, out.String())
+			}
+		})
+	}
+
+	// -r skips the digest line
+	var dump, reverted, errOut bytes.Buffer
+	runMain([]string{"--hash", "sha256"}, strings.NewReader(input), &dump, &errOut)
+	if code := runMain([]string{"-r"}, &dump, &reverted, &errOut); code != exitOK {
+		t.Fatalf("exit code %d reverting, stderr: %q", code, errOut.String())
+	}
+	assertEqual(t, reverted.String(), input)
+
+	// none is the default and prints nothing
+	var plain, hashed bytes.Buffer
+	runMain(nil, strings.NewReader(input), &plain, &errOut)
+	runMain([]string{"--hash", "none", "-p"}, strings.NewReader(input), &hashed, &errOut)
+	if strings.Contains(plain.String(), "#") || strings.Contains(hashed.String(), "#") {
+		t.Errorf("unexpected digest line without --hash")
+	}
+
+	for _, args := range [][]string{{"--hash", "crc32"}, {"--hash", "md5", "-p"}, {"--hash", "sha1", "--json"}, {"--hash", "sha256", "--split-output", "1"}} {
+		if code := runMain(args, strings.NewReader(input), &bytes.Buffer{}, &bytes.Buffer{}); code != exitUsage {
+			t.Errorf("runMain(%q) exit code %d, want %d", args, code, exitUsage)
+		}
+	}
+	// The digest line is the last line of the dump, --split-output would give it a file of its own
+	errOut.Reset()
+	runMain([]string{"--hash", "sha256", "--split-output", "1"}, strings.NewReader(input), &bytes.Buffer{}, &errOut)
+	if !strings.Contains(errOut.String(), "can't be combined with --split-output") {
+		t.Errorf("stderr %q does not name --split-output", errOut.String())
+	}
+}
@@ -0,0 +1,82 @@
+package ccxxd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// cIncludeLenTypes are the C types --include-len-type accepts, the first is xxd's
+var cIncludeLenTypes = []string{"unsigned int", "size_t", "unsigned long"}
+
+// printCInclude writes the input as a C array and its length, like xxd -i.
+// The names come from the input file name, stdin gives just the array body.
+func (cmd *command) printCInclude() error {
+	err := skipToOffset(cmd.input, cmd.input, cmd.startOffset)
+	if err != nil {
+		return err
+	}
+	reader := cmd.input
+	if cmd.maxBytes >= 0 {
+		reader = io.LimitReader(reader, cmd.maxBytes)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("error reading input: %v", err)
+	}
+	err = cmd.teeBytes(data)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(cmd.output)
+	name := cIdentifier(cmd.inputName)
+	qualifier := ""
+	if cmd.cConst {
+		qualifier = "const "
+	}
+	lenType := cmd.cLenType
+	if lenType == "" {
+		lenType = cIncludeLenTypes[0]
+	}
+	if name != "" {
+		fmt.Fprintf(writer, "%sunsigned char %s[] = {\n", qualifier, name)
+	}
+	for i, b := range data {
+		switch {
+		case i == 0:
+			writer.WriteString("  ")
+		case i%cmd.bytesPerLine == 0:
+			writer.WriteString(",\n  ")
+		default:
+			writer.WriteString(", ")
+		}
+		fmt.Fprintf(writer, "0x"+cmd.hexFormat(), b)
+	}
+	if len(data) > 0 {
+		writer.WriteString("\n")
+	}
+	if name != "" {
+		fmt.Fprintf(writer, "};\n%s%s %s_len = %d;\n", qualifier, lenType, name, len(data))
+	}
+	return writer.Flush()
+}
+
+// cIdentifier turns a file name into a C identifier the way xxd -i does:
+// anything but letters and digits becomes '_', and a leading digit gets a "__" prefix.
+func cIdentifier(fileName string) string {
+	if fileName == "" {
+		return ""
+	}
+	name := strings.Map(func(r rune) rune {
+		if r < 128 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, fileName)
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "__" + name
+	}
+	return name
+}
@@ -0,0 +1,91 @@
+package ccxxd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCInclude(t *testing.T) {
+	tests := []struct {
+		name      string
+		inputName string
+		input     string
+		constant  bool
+		lenType   string
+		want      string
+	}{
+		{
+			// Captured from: xxd -i my-file.1.bin
+			name:      "named file",
+			inputName: "my-file.1.bin",
+			input:     "Hello, include mode!\n",
+			want: `unsigned char my_file_1_bin[] = {
+  0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x2c, 0x20, 0x69, 0x6e, 0x63, 0x6c, 0x75,
+  0x64, 0x65, 0x20, 0x6d, 0x6f, 0x64, 0x65, 0x21, 0x0a
+};
+unsigned int my_file_1_bin_len = 21;
+`,
+		},
+		{
+			name:  "stdin is anonymous",
+			input: "Hello, include mode!\n",
+			want: `  0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x2c, 0x20, 0x69, 0x6e, 0x63, 0x6c, 0x75,
+  0x64, 0x65, 0x20, 0x6d, 0x6f, 0x64, 0x65, 0x21, 0x0a
+`,
+		},
+		{
+			name:      "empty file with leading digit",
+			inputName: "1st.bin",
+			want: `unsigned char __1st_bin[] = {
+};
+unsigned int __1st_bin_len = 0;
+`,
+		},
+		{
+			name:      "const with size_t length",
+			inputName: "blob.bin",
+			input:     "const",
+			constant:  true,
+			lenType:   "size_t",
+			want: `const unsigned char blob_bin[] = {
+  0x63, 0x6f, 0x6e, 0x73, 0x74
+};
+const size_t blob_bin_len = 5;
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader(tt.input),
+				inputName:    tt.inputName,
+				bytesPerLine: defaultColsCInclude,
+				maxBytes:     -1,
+				cInclude:     true,
+				cConst:       tt.constant,
+				cLenType:     tt.lenType,
+			}
+			assertNoError(t, cmd.printCInclude())
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
+
+func TestCIncludeSkipNonSeekable(t *testing.T) {
+	// A pipe can't seek, so -s reads up to the offset instead
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        onlyReader{strings.NewReader("skip me, keep me")},
+		bytesPerLine: defaultColsCInclude,
+		startOffset:  9,
+		maxBytes:     4,
+		cInclude:     true,
+	}
+	assertNoError(t, cmd.printCInclude())
+	assertEqual(t, out.String(), "  0x6b, 0x65, 0x65, 0x70\n")
+}
@@ -0,0 +1,8 @@
+// Command ccxxd is a hex dump tool compatible with xxd.
+package main
+
+import "github.com/boxy-pug/ccxxd"
+
+func main() {
+	ccxxd.Main()
+}
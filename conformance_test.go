@@ -0,0 +1,88 @@
+package ccxxd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "Regenerate testdata/xxd/*.golden with the xxd on PATH")
+
+// conformanceInput holds every byte value, some text and a run of zeros, so the
+// matrix below covers the ascii panel, -a and short last lines.
+const conformanceInput = "testdata/conformance.bin"
+
+// TestXxdConformance compares ccxxd with golden files captured from real xxd
+// (2022-01-14) for a matrix of layout flags. Run with -update-golden to recapture them.
+//
+// Left out on purpose: -u, where ccxxd also upper cases the offsets, and -e with a -c
+// that isn't a multiple of -g, where xxd runs the ascii panel into the hex.
+func TestXxdConformance(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"default", nil},
+		{"c1", []string{"-c", "1"}},
+		{"c5", []string{"-c", "5"}},
+		{"c8", []string{"-c", "8"}},
+		{"c11", []string{"-c", "11"}},
+		{"c13", []string{"-c", "13"}},
+		{"c32", []string{"-c", "32"}},
+		{"g1", []string{"-g", "1"}},
+		{"g3", []string{"-g", "3"}},
+		{"g4", []string{"-g", "4"}},
+		{"g8", []string{"-g", "8"}},
+		{"g0", []string{"-g", "0"}},
+		{"c8_g3", []string{"-c", "8", "-g", "3"}},
+		{"c13_g5", []string{"-c", "13", "-g", "5"}},
+		{"e", []string{"-e"}},
+		{"e_g2", []string{"-e", "-g", "2"}},
+		{"e_g8", []string{"-e", "-g", "8"}},
+		{"e_c8", []string{"-e", "-c", "8"}},
+		{"e_c4_g1", []string{"-e", "-c", "4", "-g", "1"}},
+		{"e_c32_g16", []string{"-e", "-c", "32", "-g", "16"}},
+		{"e_c12_g4", []string{"-e", "-c", "12", "-g", "4"}},
+		{"e_g0_c8", []string{"-e", "-g", "0", "-c", "8"}},
+		{"l0", []string{"-l", "0"}},
+		{"l1", []string{"-l", "1"}},
+		{"l17", []string{"-l", "17"}},
+		{"l1000", []string{"-l", "1000"}},
+		{"c8_g3_l21", []string{"-c", "8", "-g", "3", "-l", "21"}},
+		{"e_l7", []string{"-e", "-l", "7"}},
+		{"e_c4_l9", []string{"-e", "-c", "4", "-l", "9"}},
+		{"s10_l40", []string{"-s", "10", "-l", "40"}},
+		{"a", []string{"-a"}},
+		{"d", []string{"-d"}},
+		{"b_c4", []string{"-b", "-c", "4"}},
+		{"p", []string{"-p"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			golden := filepath.Join("testdata", "xxd", tt.name+".golden")
+			args := append(append([]string{}, tt.args...), conformanceInput)
+
+			if *updateGolden {
+				want, err := exec.Command("xxd", args...).Output()
+				if err != nil {
+					t.Fatalf("running xxd %v: %v", strings.Join(args, " "), err)
+				}
+				assertNoError(t, os.WriteFile(golden, want, 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			assertNoError(t, err)
+
+			var stdout, stderr bytes.Buffer
+			code := runMain(args, strings.NewReader(""), &stdout, &stderr)
+			if code != exitOK {
+				t.Fatalf("exit %d: %s", code, stderr.String())
+			}
+			assertEqual(t, stdout.String(), string(want))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package ccxxd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// printCSVHeader sets up the csv writer and writes the "offset,b0,...,bN,ascii" header.
+func (cmd *command) printCSVHeader() error {
+	cmd.csvWriter = csv.NewWriter(cmd.output)
+
+	header := make([]string, 0, cmd.bytesPerLine+2)
+	header = append(header, "offset")
+	for i := range cmd.bytesPerLine {
+		header = append(header, "b"+strconv.Itoa(i))
+	}
+	header = append(header, "ascii")
+	return cmd.csvWriter.Write(header)
+}
+
+// printCSVLine writes one csv row. Short lines get empty byte columns
+// so every row has the same number of fields.
+func (cmd *command) printCSVLine(offset int64, line []byte) error {
+	record := make([]string, 0, cmd.bytesPerLine+2)
+
+	if cmd.csvDecimal {
+		record = append(record, strconv.FormatInt(cmd.shownOffset(offset), 10))
+	} else {
+		record = append(record, fmt.Sprintf("%08x", cmd.shownOffset(offset)))
+	}
+
+	for i := range cmd.bytesPerLine {
+		switch {
+		case i >= len(line):
+			record = append(record, "")
+		case cmd.csvDecimal:
+			record = append(record, strconv.Itoa(int(line[i])))
+		default:
+			record = append(record, fmt.Sprintf("%02x", line[i]))
+		}
+	}
+
+	var ascii strings.Builder
+	cmd.printASCII(line, &ascii)
+	record = append(record, ascii.String())
+
+	return cmd.csvWriter.Write(record)
+}
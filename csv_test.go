@@ -0,0 +1,146 @@
+package ccxxd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestCSV(t *testing.T) {
+	tests := []struct {
+		name       string
+		csvDecimal bool
+		want       string
+	}{
+		{
+			name: "Hex columns",
+			want: `offset,b0,b1,b2,b3,b4,b5,ascii
+00000000,48,65,6c,6c,6f,2c,"Hello,"
+00000006,20,22,77,22,0a,," ""w""."
+`,
+		},
+		{
+			name:       "Decimal columns",
+			csvDecimal: true,
+			want: `offset,b0,b1,b2,b3,b4,b5,ascii
+0,72,101,108,108,111,44,"Hello,"
+6,32,34,119,34,10,," ""w""."
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader("Hello, \"w\"\n"),
+				bytesPerLine: 6,
+				groupSize:    2,
+				maxBytes:     -1,
+				csv:          true,
+				csvDecimal:   tc.csvDecimal,
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tc.want)
+
+			// Every row must parse with the same column count: offset, 6 bytes, ascii
+			records, err := csv.NewReader(&out).ReadAll()
+			assertNoError(t, err)
+			if len(records) != 3 {
+				t.Fatalf("got %d records, want 3", len(records))
+			}
+			for _, record := range records {
+				if len(record) != 8 {
+					t.Errorf("got %d columns, want 8: %q", len(record), record)
+				}
+			}
+		})
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	original := []byte("\x00\x01binary,\"csv\"\n\xff\xfe round trip")
+
+	for _, decimal := range []bool{false, true} {
+		var dump bytes.Buffer
+		cmd := command{
+			output:       &dump,
+			input:        bytes.NewReader(original),
+			bytesPerLine: 7,
+			groupSize:    2,
+			maxBytes:     -1,
+			csv:          true,
+			csvDecimal:   decimal,
+		}
+		assertNoError(t, cmd.run())
+
+		var reverted bytes.Buffer
+		err := revertToBinary(&dump, &reverted, cmd.revertOptions())
+		assertNoError(t, err)
+
+		if !bytes.Equal(reverted.Bytes(), original) {
+			t.Errorf("decimal=%v\nGOT:  %q\nWANT: %q", decimal, reverted.Bytes(), original)
+		}
+	}
+}
+
+func TestCSVRevertGap(t *testing.T) {
+	input := "offset,b0,b1,ascii\n00000002,41,42,AB\n00000006,43,,C\n"
+
+	var reverted bytes.Buffer
+	err := revertToBinary(strings.NewReader(input), &reverted, revertOptions{csv: true})
+	assertNoError(t, err)
+
+	want := []byte("\x00\x00AB\x00\x00C")
+	if !bytes.Equal(reverted.Bytes(), want) {
+		t.Errorf("GOT:  %q\nWANT: %q", reverted.Bytes(), want)
+	}
+}
+
+func TestCSVRevertDetectBase(t *testing.T) {
+	original := []byte("\x00\x01binary,\"csv\"\n\xff\xfe detected")
+
+	// Without --csv or --csv-decimal the base comes from the rows
+	for _, decimal := range []bool{false, true} {
+		var dump bytes.Buffer
+		cmd := command{
+			output:       &dump,
+			input:        bytes.NewReader(original),
+			bytesPerLine: 7,
+			groupSize:    2,
+			maxBytes:     -1,
+			csv:          true,
+			csvDecimal:   decimal,
+		}
+		assertNoError(t, cmd.run())
+
+		var reverted bytes.Buffer
+		err := revertToBinary(&dump, &reverted, revertOptions{})
+		assertNoError(t, err)
+
+		if !bytes.Equal(reverted.Bytes(), original) {
+			t.Errorf("decimal=%v\nGOT:  %q\nWANT: %q", decimal, reverted.Bytes(), original)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "no row tells", input: "offset,b0,b1,ascii\n12345678,41,42,AB\n", wantErr: "can't tell if the csv dump is hex or decimal"},
+		{name: "rows disagree", input: "offset,b0,ascii\n0,ff,.\n", wantErr: "mixes hex and decimal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reverted bytes.Buffer
+			err := revertToBinary(strings.NewReader(tt.input), &reverted, revertOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+}
@@ -0,0 +1,114 @@
+// Package ccxxd makes and reverts xxd style hex dumps.
+// The ccxxd command is a thin wrapper around Main, other programs can call Dump directly.
+package ccxxd
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Zero Options fields pick the defaults, so -g 0 and -l 0 have values of their own.
+const (
+	OneGroup = -1 // GroupSize for a single group per line, as -g 0 gives
+	NoBytes  = -1 // MaxBytes to dump nothing, as -l 0 does
+)
+
+// Options sets the layout of a Dump. The zero value gives xxd's default layout.
+type Options struct {
+	BytesPerLine  int   // Bytes per line, if zero 16, or 6 with Binary and 30 with Plain (-c)
+	GroupSize     int   // Bytes per hex group or OneGroup, if zero 2, or 4 with LittleEndian and 1 with Binary (-g)
+	LittleEndian  bool  // Show each group in little-endian order (-e)
+	StartOffset   int64 // Offset of the first byte to dump (-s)
+	MaxBytes      int64 // Stop after this many bytes or dump NoBytes, if zero dump everything (-l)
+	DisplayOffset int64 // Added to the offsets shown, reading is unaffected (-o)
+	Uppercase     bool  // Upper case hex digits and offsets (-u)
+	Autoskip      bool  // Collapse runs of all-zero lines into "*" (-a)
+	Binary        bool  // Print bits instead of hex digits (-b)
+	Plain         bool  // Plain hex without offsets or ascii (-p)
+}
+
+// Dump writes a hex dump of r to w. If r is an io.Seeker, StartOffset is reached
+// by seeking, otherwise the bytes before it are read and dropped.
+func Dump(w io.Writer, r io.Reader, opts Options) error {
+	return DumpContext(context.Background(), w, r, opts)
+}
+
+// DumpContext is Dump, but checks ctx before every line. Once ctx is done it returns
+// an error wrapping ctx.Err(), the lines written up to then are kept.
+func DumpContext(ctx context.Context, w io.Writer, r io.Reader, opts Options) error {
+	cmd, err := opts.command(w, r)
+	if err != nil {
+		return err
+	}
+	return cmd.runContext(ctx)
+}
+
+// Revert decodes the hex dump read from r and writes the bytes to w. The dump format
+// (xxd, plain, Intel HEX, base64, csv) is detected from its first line. Pass the
+// Options the dump was made with: LittleEndian dumps have their groups reversed back.
+// Errors caused by a line of the dump are *LineError.
+func Revert(w io.Writer, r io.Reader, opts Options) error {
+	if opts.Binary {
+		return fmt.Errorf("reverting binary digit dumps is not supported")
+	}
+	// Work out the group size exactly as Dump did
+	cmd, err := opts.command(w, r)
+	if err != nil {
+		return err
+	}
+	return revertToBinary(r, w, revertOptions{littleEndian: cmd.littleEndian, groupSize: cmd.groupSize})
+}
+
+// command builds the command that dumps r to w with these options.
+func (opts Options) command(w io.Writer, r io.Reader) (command, error) {
+	cmd := command{
+		output:        w,
+		input:         r,
+		bytesPerLine:  opts.BytesPerLine,
+		groupSize:     opts.GroupSize,
+		littleEndian:  opts.LittleEndian,
+		startOffset:   opts.StartOffset,
+		maxBytes:      opts.MaxBytes,
+		displayOffset: opts.DisplayOffset,
+		uppercase:     opts.Uppercase,
+		autoskip:      opts.Autoskip,
+		binary:        opts.Binary,
+		plain:         opts.Plain,
+	}
+	// Same defaults as the command line, -b and -p have their own
+	switch {
+	case cmd.bytesPerLine > 0:
+	case cmd.binary:
+		cmd.bytesPerLine = defaultColsBinary
+	case cmd.plain:
+		cmd.bytesPerLine = defaultColsPlain
+	default:
+		cmd.bytesPerLine = defaultCols
+	}
+	switch {
+	case opts.GroupSize == OneGroup:
+		cmd.groupSize = 0 // validateByteGrouping makes it the whole line
+	case cmd.groupSize > 0:
+	case cmd.groupSize < 0:
+		return cmd, fmt.Errorf("invalid GroupSize %d, use OneGroup for a single group per line", opts.GroupSize)
+	case cmd.binary:
+		cmd.groupSize = defaultGroupSizeBinary
+	case cmd.littleEndian:
+		cmd.groupSize = defaultGroupSizeLittleEndian
+	default:
+		cmd.groupSize = defaultGroupSize
+	}
+	switch {
+	case opts.MaxBytes == NoBytes:
+		cmd.maxBytes = 0
+	case opts.MaxBytes == 0:
+		cmd.maxBytes = -1
+	case opts.MaxBytes < 0:
+		return cmd, fmt.Errorf("invalid MaxBytes %d, use NoBytes to dump nothing", opts.MaxBytes)
+	}
+
+	var err error
+	cmd.groupSize, err = validateByteGrouping(cmd.groupSize, cmd.bytesPerLine, cmd.littleEndian)
+	return cmd, err
+}
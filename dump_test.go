@@ -0,0 +1,147 @@
+package ccxxd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDump(t *testing.T) {
+	input := "Dump is the library entry point"
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "zero options match xxd defaults",
+			want: `00000000: 4475 6d70 2069 7320 7468 6520 6c69 6272  Dump is the libr
+00000010: 6172 7920 656e 7472 7920 706f 696e 74    ary entry point
+`,
+		},
+		{
+			name: "columns and groups",
+			opts: Options{BytesPerLine: 8, GroupSize: 4, MaxBytes: 12},
+			want: `00000000: 44756d70 20697320  Dump is 
+00000008: 74686520           the 
+`,
+		},
+		{
+			name: "offset and length",
+			opts: Options{StartOffset: 12, MaxBytes: 11, Uppercase: true},
+			want: `0000000C: 6C69 6272 6172 7920 656E 74              library ent
+`,
+		},
+		{
+			name: "one group per line",
+			opts: Options{BytesPerLine: 8, GroupSize: OneGroup, MaxBytes: 12},
+			want: `00000000: 44756d7020697320  Dump is 
+00000008: 74686520          the 
+`,
+		},
+		{
+			name: "no bytes",
+			opts: Options{MaxBytes: NoBytes},
+			want: "",
+		},
+		{
+			name: "binary defaults",
+			opts: Options{Binary: true, MaxBytes: 6},
+			want: `00000000: 01000100 01110101 01101101 01110000 00100000 01101001  Dump i
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			assertNoError(t, Dump(&out, strings.NewReader(input), tt.opts))
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
+
+func TestDumpInvalidOptions(t *testing.T) {
+	for _, opts := range []Options{{GroupSize: -2}, {MaxBytes: -2}} {
+		if err := Dump(&bytes.Buffer{}, strings.NewReader("x"), opts); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
+
+func TestRevert(t *testing.T) {
+	original := "Revert takes back what Dump wrote\x00\xff"
+
+	for _, opts := range []Options{
+		{},
+		{BytesPerLine: 5, GroupSize: 3},
+		{BytesPerLine: 8, LittleEndian: true},
+		{BytesPerLine: 12, GroupSize: 2, LittleEndian: true, Uppercase: true},
+		{Plain: true},
+	} {
+		var dump, reverted bytes.Buffer
+		assertNoError(t, Dump(&dump, strings.NewReader(original), opts))
+		assertNoError(t, Revert(&reverted, strings.NewReader(dump.String()), opts))
+		if reverted.String() != original {
+			t.Errorf("%+v: GOT %q WANT %q", opts, reverted.String(), original)
+		}
+	}
+}
+
+func TestRevertLineError(t *testing.T) {
+	dump := "00000000: 5265 7665  Reve\n00000004: 72zz 2074  r. t\n"
+	err := Revert(&bytes.Buffer{}, strings.NewReader(dump), Options{})
+
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) {
+		t.Fatalf("expected a *LineError, got %v", err)
+	}
+	if lineErr.Line != 2 {
+		t.Errorf("got line %d, want 2", lineErr.Line)
+	}
+}
+
+// cancelReader cancels its context once more than after bytes have been read.
+type cancelReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+	after  int
+	read   int
+}
+
+func (c *cancelReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	if c.read > c.after {
+		c.cancel()
+	}
+	return n, err
+}
+
+func TestDumpContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// One byte per read, so the cancel lands in the middle of the third line
+	input := &cancelReader{
+		r:      iotest.OneByteReader(strings.NewReader(strings.Repeat("cancel me ", 1000))),
+		cancel: cancel,
+		after:  40,
+	}
+
+	var out bytes.Buffer
+	err := DumpContext(ctx, &out, input, Options{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	// The line being read when the context was cancelled is still finished
+	want := `00000000: 6361 6e63 656c 206d 6520 6361 6e63 656c  cancel me cancel
+00000010: 206d 6520 6361 6e63 656c 206d 6520 6361   me cancel me ca
+00000020: 6e63 656c 206d 6520 6361 6e63 656c 206d  ncel me cancel m
+`
+	assertEqual(t, out.String(), want)
+}
@@ -0,0 +1,27 @@
+package ccxxd
+
+// ebcdicPanel is the -E character panel for EBCDIC (code page 37) bytes, 16 per row.
+// Bytes without a printable ASCII equivalent are shown as '.', the same as xxd -E.
+const ebcdicPanel = "" +
+	"................" + // 0x00
+	"................" + // 0x10
+	"................" + // 0x20
+	"................" + // 0x30
+	" ...........<(+|" + // 0x40
+	"&.........!$*);~" + // 0x50
+	"-/.........,%_>?" + // 0x60
+	".........`:#@'=\"" + // 0x70
+	".abcdefghi......" + // 0x80
+	".jklmnopqr^....." + // 0x90
+	"..stuvwxyz...[.." + // 0xa0
+	".............].." + // 0xb0
+	"{ABCDEFGHI......" + // 0xc0
+	"}JKLMNOPQR......" + // 0xd0
+	"\\.STUVWXYZ......" + // 0xe0
+	"0123456789......" // 0xf0
+
+// ebcdicChar returns the ASCII character for EBCDIC byte b, ok is false if it has none.
+func ebcdicChar(b byte) (c byte, ok bool) {
+	c = ebcdicPanel[b]
+	return c, c != '.' || b == 0x4b // 0x4b is EBCDIC's own '.'
+}
@@ -0,0 +1,46 @@
+package ccxxd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEBCDIC(t *testing.T) {
+	// "Hello, IBM 370!" in code page 37, then a control byte
+	original := "\xc8\x85\x93\x93\x96\x6b\x40\xc9\xc2\xd4\x40\xf3\xf7\xf0\x5a\x25"
+
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        strings.NewReader(original),
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     -1,
+		charset:      charsets["ebcdic"],
+	}
+	assertNoError(t, cmd.run())
+
+	// Captured from: xxd -E
+	want := "00000000: c885 9393 966b 40c9 c2d4 40f3 f7f0 5a25  Hello, IBM 370!.\n"
+	assertEqual(t, dump.String(), want)
+
+	tests := []struct {
+		b    byte
+		want byte
+		ok   bool
+	}{
+		{b: 0xc1, want: 'A', ok: true},
+		{b: 0x81, want: 'a', ok: true},
+		{b: 0xf9, want: '9', ok: true},
+		{b: 0x4b, want: '.', ok: true},
+		{b: 0x41, ok: false},
+		{b: 'A', ok: false},
+	}
+	for _, tc := range tests {
+		got, ok := ebcdicChar(tc.b)
+		if ok != tc.ok || (ok && got != tc.want) {
+			t.Errorf("ebcdicChar(0x%02x) = %q, %v, want %q, %v", tc.b, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package ccxxd
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// defaultPollInterval is how long --follow waits for new data by default, as tail -f does.
+const defaultPollInterval = time.Second
+
+// timerFunc returns a channel that receives once d has passed, like time.After.
+type timerFunc func(d time.Duration) <-chan time.Time
+
+// followReader reads a growing input like tail -f: at the end of the input it
+// waits and tries again instead of returning io.EOF, until ctx is done.
+// Reads of the input run in the background, so a read blocked on an idle pipe
+// doesn't keep the dump from stopping.
+type followReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	interval time.Duration // --poll-interval between reads at the end of the input
+	after    timerFunc     // Timer source, time.After outside of tests
+	reads    chan followRead
+	pending  []byte // Bytes read but not yet returned
+}
+
+// followRead is the outcome of one background read of the input.
+type followRead struct {
+	data []byte
+	err  error
+}
+
+// Read returns io.EOF only once ctx is done, so a partial last line is still dumped.
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		if len(f.pending) > 0 {
+			n := copy(p, f.pending)
+			f.pending = f.pending[n:]
+			return n, nil
+		}
+		if f.reads == nil {
+			// At most one read is in flight, a read abandoned when ctx is done ends with the program
+			f.reads = make(chan followRead, 1)
+			go func(reads chan<- followRead, buf []byte) {
+				n, err := f.reader.Read(buf)
+				reads <- followRead{buf[:n], err}
+			}(f.reads, make([]byte, len(p)))
+		}
+
+		var read followRead
+		select {
+		case <-f.ctx.Done():
+			return 0, io.EOF
+		case read = <-f.reads:
+			f.reads = nil
+		}
+		f.pending = read.data
+		if len(f.pending) > 0 {
+			continue
+		}
+		if read.err != io.EOF {
+			return 0, read.err
+		}
+		select {
+		case <-f.ctx.Done():
+			return 0, io.EOF
+		case <-f.after(f.interval):
+		}
+	}
+}
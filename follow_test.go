@@ -0,0 +1,129 @@
+package ccxxd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFollow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var input bytes.Buffer
+	input.WriteString("0123456789abcdefta")
+	var out bytes.Buffer
+	var waits []time.Duration
+	var flushed []string
+
+	// Each wait sees what was dumped so far, grows the input once, then stops the dump
+	after := func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		flushed = append(flushed, out.String())
+		switch len(waits) {
+		case 1:
+			input.WriteString("il")
+		case 2:
+			cancel()
+		}
+		ready := make(chan time.Time, 1)
+		ready <- time.Time{}
+		return ready
+	}
+
+	cmd := command{
+		input:        &input,
+		output:       &out,
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     -1,
+		follow:       true,
+		pollInterval: 250 * time.Millisecond,
+		after:        after,
+	}
+	err := cmd.runContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("runContext error %v, want context.Canceled", err)
+	}
+
+	first := "00000000: 3031 3233 3435 3637 3839 6162 6364 6566  0123456789abcdef\n"
+	assertEqual(t, out.String(), first+
+		"00000010: 7461 696c                                tail\n")
+	if len(waits) < 2 {
+		t.Fatalf("waited %d times, want at least 2", len(waits))
+	}
+	for i, d := range waits {
+		if d != 250*time.Millisecond {
+			t.Errorf("wait %d was %v, want the 250ms poll interval", i, d)
+		}
+	}
+	// Complete lines are written out before waiting for more input
+	assertEqual(t, flushed[0], first)
+}
+
+func TestFollowBlockedRead(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The writer stays open, so reading past "tail" blocks like an idle pipe
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	go func() {
+		writer.Write([]byte("tail"))
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	var out bytes.Buffer
+	cmd := command{
+		input:        reader,
+		output:       &out,
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     -1,
+		follow:       true,
+		pollInterval: time.Hour,
+		after:        time.After,
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.runContext(ctx) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("runContext error %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runContext kept waiting on the blocked read after the context was canceled")
+	}
+	assertEqual(t, out.String(), "00000000: 7461 696c                                tail\n")
+}
+
+func TestFollowFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantStderr string
+	}{
+		{name: "poll interval without unit", args: []string{"--follow", "--poll-interval", "5"}, wantStderr: "invalid value \"5\" for flag -poll-interval"},
+		{name: "zero poll interval", args: []string{"--follow", "--poll-interval", "0s"}, wantStderr: "--poll-interval must be positive"},
+		{name: "follow with -c 0", args: []string{"--follow", "-c", "0"}, wantStderr: "-c 0 needs the whole input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			code := runMain(tt.args, strings.NewReader(""), &out, &errOut)
+			if code != exitUsage {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitUsage, errOut.String())
+			}
+			if !strings.Contains(errOut.String(), tt.wantStderr) {
+				t.Errorf("stderr %q does not contain %q", errOut.String(), tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package ccxxd
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// printHTMLHeader opens the --html table and writes the column headings.
+func (cmd *command) printHTMLHeader() {
+	fmt.Fprintln(cmd.output, "<table>")
+	fmt.Fprintln(cmd.output, "<tr><th>offset</th><th>hex</th><th>ascii</th></tr>")
+}
+
+// printHTMLLine writes one table row. The ascii panel is html escaped,
+// non-printable bytes are already replaced by printASCII.
+func (cmd *command) printHTMLLine(offset int64, line []byte) {
+	var hexField, ascii strings.Builder
+	if cmd.littleEndian {
+		cmd.printLittleEndianHex(line, &hexField)
+	} else {
+		cmd.printHex(line, &hexField)
+	}
+	cmd.printASCII(line, &ascii)
+
+	fmt.Fprintf(cmd.output, "<tr><td>%08x</td><td>%s</td><td>%s</td></tr>\n",
+		cmd.shownOffset(offset), strings.TrimSpace(hexField.String()), html.EscapeString(ascii.String()))
+}
+
+// printHTMLFooter closes the --html table.
+func (cmd *command) printHTMLFooter() {
+	fmt.Fprintln(cmd.output, "</table>")
+}
@@ -0,0 +1,41 @@
+package ccxxd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("<a href=\"x\">&</a>\x00"),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		html:         true,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `<table>
+<tr><th>offset</th><th>hex</th><th>ascii</th></tr>
+<tr><td>00000000</td><td>3c61 2068 7265 663d</td><td>&lt;a href=</td></tr>
+<tr><td>00000008</td><td>2278 223e 263c 2f61</td><td>&#34;x&#34;&gt;&amp;&lt;/a</td></tr>
+<tr><td>00000010</td><td>3e00</td><td>&gt;.</td></tr>
+</table>
+`
+	assertEqual(t, out.String(), want)
+
+	// The table must be well-formed markup
+	decoder := xml.NewDecoder(&out)
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		assertNoError(t, err)
+	}
+}
@@ -0,0 +1,64 @@
+package ccxxd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// jsonLine is one element of the --json array
+type jsonLine struct {
+	Offset int64  `json:"offset"`
+	Hex    string `json:"hex"`
+	ASCII  string `json:"ascii"`
+}
+
+// printJSONHeader opens the --json array and sets up the encoder for its lines.
+func (cmd *command) printJSONHeader() error {
+	cmd.jsonEncoder = json.NewEncoder(&cmd.jsonBuffer)
+	cmd.jsonEncoder.SetEscapeHTML(false)
+	cmd.jsonLines = 0
+	_, err := io.WriteString(cmd.output, "[")
+	return err
+}
+
+// printJSONLine writes one line object, with the hex grouped as in the normal dump.
+// Each line is written as soon as it's encoded, so the array streams.
+func (cmd *command) printJSONLine(offset int64, line []byte) error {
+	var hexField, ascii strings.Builder
+	if cmd.littleEndian {
+		cmd.printLittleEndianHex(line, &hexField)
+	} else {
+		cmd.printHex(line, &hexField)
+	}
+	cmd.printASCII(line, &ascii)
+
+	cmd.jsonBuffer.Reset()
+	err := cmd.jsonEncoder.Encode(jsonLine{
+		Offset: cmd.shownOffset(offset),
+		Hex:    strings.TrimSpace(hexField.String()),
+		ASCII:  ascii.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n  "
+	if cmd.jsonLines == 0 {
+		separator = "\n  "
+	}
+	cmd.jsonLines++
+	_, err = io.WriteString(cmd.output, separator+string(bytes.TrimSuffix(cmd.jsonBuffer.Bytes(), []byte("\n"))))
+	return err
+}
+
+// printJSONFooter closes the --json array, an empty dump gives [].
+func (cmd *command) printJSONFooter() error {
+	footer := "\n]\n"
+	if cmd.jsonLines == 0 {
+		footer = "]\n"
+	}
+	_, err := io.WriteString(cmd.output, footer)
+	return err
+}
@@ -0,0 +1,44 @@
+package ccxxd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	var dump bytes.Buffer
+	cmd := command{
+		output:        &dump,
+		input:         strings.NewReader("Hello, \"json\" <dump>!\x00"),
+		bytesPerLine:  8,
+		groupSize:     4,
+		maxBytes:      -1,
+		startOffset:   2,
+		displayOffset: 0x100,
+		json:          true,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `[
+  {"offset":258,"hex":"6c6c6f2c 20226a73","ascii":"llo, \"js"},
+  {"offset":266,"hex":"6f6e2220 3c64756d","ascii":"on\" <dum"},
+  {"offset":274,"hex":"703e2100","ascii":"p>!."}
+]
+`
+	assertEqual(t, dump.String(), want)
+
+	var lines []jsonLine
+	assertNoError(t, json.Unmarshal(dump.Bytes(), &lines))
+	if len(lines) != 3 || lines[2].Offset != 274 || lines[2].Hex != "703e2100" {
+		t.Errorf("unexpected decoded lines %+v", lines)
+	}
+
+	// Empty input is still a valid, empty array
+	dump.Reset()
+	cmd.input = strings.NewReader("")
+	cmd.startOffset = 0
+	assertNoError(t, cmd.run())
+	assertEqual(t, dump.String(), "[]\n")
+}
@@ -1,145 +1,1274 @@
-package main
+package ccxxd
 
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"hash"
 	"io"
+	"math"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	defaultGroupSize             = 2
 	defaultGroupSizeLittleEndian = 4
 	defaultCols                  = 16
-	offsetCharWidth              = 10
+	defaultColsBinary            = 6 // xxd -b prints 6 bytes per line
+	defaultGroupSizeBinary       = 1
+	defaultColsPlain             = 30        // xxd -p prints 30 bytes per line
+	defaultColsCInclude          = 12        // xxd -i prints 12 bytes per line
+	offsetCharWidth              = 10        // Width of a minimal "00000000: " offset column
+	minOffsetDigits              = 8         // xxd never prints fewer offset digits
+	percentCharWidth             = 5         // "100% " column printed by --percent
+	unknownLength                = 1<<63 - 1 // End offset used when input size can't be determined
+	timestampFormat              = "15:04:05.000"
+	timestampCharWidth           = len(timestampFormat) + 1
 )
 
 type command struct {
 	input          io.Reader // Input file (or stdin)
+	inputName      string    // Name of the input file, empty for stdin
 	output         io.Writer
-	endOffset      int64 // Where to stop reading (byte offset)
-	littleEndian   bool  // -e Output in little-endian order
-	groupSize      int   // -g <int> default 2, byte grouping
-	bytesPerLine   int   // -c <int> octets per line. default 16
-	maxBytes       int64 // -l <int> stop writing after len octets
-	startOffset    int64 // -s <offset> (which byte to start reading from)
-	revert         bool  // -r Reverse operation: convert (or patch) hex dump into binary
-	wantedHexWidth int   // Helper for little endian formatting
-}
-
-func main() {
-	cmd, err := loadCommand()
-	if err != nil {
-		fmt.Println("error loading command:", err)
-		os.Exit(1)
+	errOutput      io.Writer        // Error and warning messages, stderr on the command line
+	endOffset      int64            // Where to stop reading (byte offset)
+	littleEndian   bool             // -e Output in little-endian order
+	binary         bool             // -b Output bits instead of hex, 8 binary digits per byte
+	uppercase      bool             // -u Use upper case hex letters
+	decimal        bool             // -d Show offsets in decimal instead of hex
+	plain          bool             // -p Plain hex dump, no offsets and no ascii panel
+	cInclude       bool             // -i Output a C include file with the bytes as an array
+	cConst         bool             // --include-const declare the -i array and length const
+	cLenType       string           // --include-len-type <type> C type of the -i length, "unsigned int" if empty
+	autoskip       bool             // -a Collapse runs of all-zero lines into a single "*" line
+	squeeze        bool             // --squeeze collapse runs of any identical lines, as -a does for zero lines
+	skipAfter      int              // --skip-after <n> with -a or --squeeze print the first n lines of a run before the marker
+	maxSkips       int              // --max-skips <n> with -a or --squeeze stop collapsing after n markers, 0 for no limit
+	skipMarker     string           // --skip-marker <s> with -a or --squeeze the line printed for skipped lines, "*" if empty, -r reads it back
+	groupSize      int              // -g <int> default 2, byte grouping
+	bytesPerLine   int              // -c <int> octets per line. default 16
+	maxBytes       int64            // -l <int> stop writing after len octets
+	displayOffset  int64            // -o <off> add <off> to the displayed offsets, reading is unaffected
+	startOffset    int64            // -s [+-]<offset> (which byte to start reading from)
+	revert         bool             // -r Reverse operation: convert (or patch) hex dump into binary
+	check          bool             // --check with -r only validate the dump, write nothing
+	tolerant       bool             // --tolerant with -r skip corrupt lines instead of stopping
+	prependBytes   []byte           // --prepend-hex <hex> with -r write these bytes before the output
+	padChar        byte             // --pad-char <char> with -r placeholder that marks missing bytes in the hex field
+	dumpFirstLine  int              // --dump-lines <a-b> with -r only revert dump lines a to b
+	dumpLastLine   int              // Last line of the --dump-lines range
+	maxWidth       int              // --max-width-auto <int> shrink bytesPerLine so lines fit within width
+	annotations    []annotation     // --annotate <spec> named byte ranges printed below each line
+	percent        bool             // --percent show offset as percentage of total size
+	timestamps     bool             // --timestamps prefix each line with the time it was read
+	clock          func() time.Time // Time source for --timestamps, defaults to time.Now
+	follow         bool             // --follow keep reading as the input grows, like tail -f
+	pollInterval   time.Duration    // --poll-interval <duration> with --follow wait between reads at the end of the input
+	after          timerFunc        // Timer source for --follow, defaults to time.After
+	checksumFiles  []string         // --compare-checksums print a checksum per file instead of dumping
+	symDiffFile    string           // --sym-diff <dump> compare the input dump with another dump
+	teeFile        *os.File         // --tee <file> copy of the dumped input bytes, closed by closeInput
+	outputFile     *os.File         // --output <file> written instead of stdout, closed by closeOutput
+	patchFile      string           // --patch <file> with -r write each line's bytes at its offset in <file>
+	revertSeek     int64            // -seek <n> with -r shift the output by <n> bytes
+	seekZeroFill   bool             // --seek-zero-fill with -seek write zeros when the output can't seek
+	splitLines     int              // --split-output <int> rotate output files every n lines
+	splitPrefix    string           // --split-prefix <name> output files are named <name>.000, <name>.001...
+	csv            bool             // --csv output rows of offset, one column per byte and ascii
+	csvDecimal     bool             // --csv-decimal print csv offset and byte columns in decimal
+	csvWriter      *csv.Writer      // Writer for --csv rows, set up in run
+	html           bool             // --html output an html table of offset, hex and ascii
+	json           bool             // -j, --json output a json array of {offset, hex, ascii} line objects
+	jsonEncoder    *json.Encoder    // Encoder for --json lines, set up in run
+	jsonBuffer     bytes.Buffer     // Holds the line jsonEncoder just encoded
+	jsonLines      int              // Number of --json lines written so far
+	od             bool             // --od mimic the output of od -A x -t x1z, -r --od reads it back
+	odVerbose      bool             // --od-verbose with --od print repeated lines instead of a "*", like od -v
+	xorKey         []byte           // --xor-key <hex> xor every byte with a repeating key
+	useMask        bool             // --mask was given
+	mask           byte             // --mask <0xNN> and every byte with a bit mask
+	bitReverse     bool             // --bit-reverse reverse the bit order of every byte, MSB<->LSB
+	selfDescribe   bool             // --self-describe start the dump with a "# ccxxd" layout header that -r reads
+	useMarker      bool             // --offset-from-marker was given
+	marker         byte             // --offset-from-marker <0xNN> show offsets relative to the first occurrence of this byte
+	markerOffset   int64            // Offset of the marker byte, set in run
+	stats          bool             // --stats print a summary of byte counts instead of a dump
+	counts         byteStats        // Byte counts for --stats, added up in run
+	hash           hash.Hash        // --hash <algo> digest of the dumped bytes, printed after the dump, nil for none
+	hashName       string           // --hash <algo> name of the algorithm, as printed
+	useFind        bool             // --find was given
+	findByte       byte             // --find <0xNN> print only the offsets where this byte occurs
+	template       string           // --template <string> custom line layout with {offset}, {hex}, {ascii}, {len}
+	rtl            bool             // --rtl print hex groups right-to-left
+	asciiWidth     int              // --ascii-width <int> show at most n characters in the ascii panel
+	ranges         []byteRange      // --ranges <file> dump several start:len ranges in sequence
+	minLines       int              // --min-lines <int> pad the dump with placeholder lines up to n lines
+	groupLines     int              // --group-lines <int> blank line between every n lines
+	revcomp        bool             // --revcomp show the nucleotide reverse complement in the ascii panel
+	charset        charsetFunc      // --charset <name> (or -E for ebcdic) decodes the ascii panel, ascii if nil
+	glyphs         map[byte]rune    // --glyphs <0xNN>=<char>,... show these bytes as the given characters in the ascii panel
+	placeholder    byte             // -ph <char> shown for non-printable bytes in the ascii panel, '.' if 0
+	showHexASCII   bool             // --show-hex-ascii list each line's text with non-printable bytes as <NN>
+	noASCII        bool             // --no-ascii leave out the ascii panel, keeping offsets and grouping
+	leASCII        bool             // --le-ascii with -e reverse the ascii panel within groups too
+	endOffsetCol   bool             // --end-offset-col append the offset of the last byte on each line
+	parity         bool             // --parity append an xor parity byte to each line, -r --parity verifies it
+	trimTrailing   bool             // --trim-trailing strip trailing padding from each line, never the panel's own spaces
+	trimPadding    bool             // --trim like --trim-trailing, and also leave out the hex padding of short lines
+	structFields   []structField    // --struct <spec> record layout, one record per line with its fields decoded below
+	xattrs         []xattr          // --xattr extended attributes of the input file, listed before the dump
+	bothEndian     bool             // --both-endian print big-endian and little-endian hex panels side by side
+	groupSpaces    int              // --group-spaces <int> spaces between hex groups, default 1
+	units          int              // --units <8|16> display bytes or 16-bit code units
+	stable         bool             // --stable diff-friendly output, one byte per line
+	offsetWidth    int              // Digits in the offset column, grown past 8 when endOffset needs more
+	wantedHexWidth int              // Helper for little endian formatting
+}
+
+// Exit codes of the command line tool
+const (
+	exitOK    = 0
+	exitError = 1 // The dump failed, e.g. on an I/O error
+	exitUsage = 2 // Invalid flags or arguments
+)
+
+// errBadFlags is returned by loadCommand when the flags don't parse. The flag set
+// has already reported the problem along with the usage.
+var errBadFlags = errors.New("invalid flags")
+
+// ioError marks a loadCommand error that comes from opening or reading files
+// rather than from the arguments, reported with exitError instead of exitUsage.
+type ioError struct {
+	err error
+}
+
+func (e *ioError) Error() string { return e.err.Error() }
+func (e *ioError) Unwrap() error { return e.err }
+
+// Main runs the ccxxd command line tool on os.Args and exits with its status.
+func Main() {
+	os.Exit(runMain(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// runMain runs the command line tool with the given arguments and standard streams
+// and returns the exit status. Errors and warnings go to stderr.
+func runMain(args []string, stdin io.Reader, stdout, stderr io.Writer) (code int) {
+	cmd, err := loadCommand(args, stdin, stdout, stderr)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errBadFlags):
+		return exitUsage
+	case err != nil:
+		fmt.Fprintln(stderr, "error loading command:", err)
+		var ioErr *ioError
+		if errors.As(err, &ioErr) {
+			return exitError
+		}
+		return exitUsage
+	}
+	defer func() {
+		// Runs after every mode, so the --tee file is closed on errors too
+		err := cmd.closeInput()
+		if err != nil && code == exitOK {
+			fmt.Fprintln(stderr, "error closing tee file:", err)
+			code = exitError
+		}
+	}()
+	defer func() {
+		// Runs after every mode, so the --output file is closed on errors too
+		err := cmd.closeOutput()
+		if err != nil && code == exitOK {
+			fmt.Fprintln(stderr, "error closing output:", err)
+			code = exitError
+		}
+	}()
+
+	// If --compare-checksums is set, print one checksum per file and exit
+	if len(cmd.checksumFiles) > 0 {
+		err := cmd.printChecksums()
+		if err != nil {
+			fmt.Fprintln(stderr, "error computing checksums:", err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	// If --sym-diff is set, compare the two dumps and exit
+	if cmd.symDiffFile != "" {
+		err := cmd.printSymDiff()
+		if err != nil {
+			fmt.Fprintln(stderr, "error comparing dumps:", err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	// If -r and --check are set, validate the dump and exit
+	if cmd.revert && cmd.check {
+		err := checkDump(cmd.input, cmd.revertOptions())
+		if err != nil {
+			fmt.Fprintln(stderr, "invalid hex dump:", err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	// If -r and --patch are set, patch the file in place and exit
+	if cmd.revert && cmd.patchFile != "" {
+		err := cmd.patch()
+		if err != nil {
+			fmt.Fprintln(stderr, "error patching file:", err)
+			return exitError
+		}
+		return exitOK
 	}
 
 	// If -r flag is set, convert hex dump to binary and exit
 	if cmd.revert {
-		err := revertToBinary(cmd.input, cmd.output)
+		err := revertToBinary(cmd.input, cmd.output, cmd.revertOptions())
 		if err != nil {
-			fmt.Fprintln(cmd.output, "error reverting to binary:", err)
-			os.Exit(1)
+			fmt.Fprintln(stderr, "error reverting to binary:", err)
+			return exitError
 		}
-		return
+		return exitOK
+	}
+
+	// If -i is set, write a C include file and exit
+	if cmd.cInclude {
+		err := cmd.printCInclude()
+		if err != nil {
+			fmt.Fprintln(stderr, "error writing C include:", err)
+			return exitError
+		}
+		return exitOK
 	}
 
-	// perform normal hex dump
-	err = cmd.run()
+	// If --ranges is set, dump each range in turn and exit
+	if len(cmd.ranges) > 0 {
+		err := cmd.runRanges()
+		if err != nil {
+			fmt.Fprintln(stderr, "error dumping ranges:", err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	// perform normal hex dump, --follow runs until interrupted
+	ctx := context.Background()
+	if cmd.follow {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+	}
+	err = cmd.runContext(ctx)
+	if cmd.follow && errors.Is(err, context.Canceled) {
+		err = nil
+	}
 	if err != nil {
-		fmt.Fprintln(cmd.output, "error running command:", err)
-		os.Exit(1)
+		fmt.Fprintln(stderr, "error running command:", err)
+		return exitError
 	}
+	return exitOK
 }
 
-// Parses command-line arguments, sets up the command struct, and opens file/stdin
-func loadCommand() (command, error) {
+// Parses command-line arguments, sets up the command struct, and opens file/stdin.
+// Failures to open or read files are returned as an *ioError, other errors are
+// problems with the arguments.
+func loadCommand(arguments []string, stdin io.Reader, stdout, stderr io.Writer) (command, error) {
 	var err error
 	cmd := command{
-		output: os.Stdout,
+		output:    stdout,
+		errOutput: stderr,
+	}
+	flags := flag.NewFlagSet("ccxxd", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	flags.BoolVar(&cmd.littleEndian, "e", false, "Print hex output in little-endian order within each group. With -r, read such a dump back.")
+	flags.BoolVar(&cmd.binary, "b", false, "Binary digit dump: print each byte as 8 bits instead of 2 hex digits (default -c 6 -g 1).")
+	flags.BoolVar(&cmd.uppercase, "u", false, "Use upper case hex letters in offsets and hex output.")
+	flags.BoolVar(&cmd.decimal, "d", false, "Show offsets in decimal instead of hex.")
+	ebcdic := flags.Bool("E", false, "Show characters in EBCDIC in the ascii panel. Hex output is unchanged. Same as --charset ebcdic.")
+	charsetName := flags.String("charset", charsetNames[0], "Decode the ascii panel as "+strings.Join(charsetNames, ", ")+", printing non-ascii characters as UTF-8. Hex output is unchanged.")
+	glyphsSpec := flags.String("glyphs", "", "Show bytes as chosen characters in the ascii panel, e.g. 0x09=→,0x0a=↵ for tabs and newlines. Takes precedence over --charset.")
+	placeholder := flags.String("ph", ".", "Show non-printable bytes as <char> in the ascii panel, any printable ascii char including space.")
+	flags.BoolVar(&cmd.plain, "p", false, "Plain hex dump: a continuous stream of hex digits without offsets or ascii (default -c 30). -r reads it back.")
+	flags.BoolVar(&cmd.plain, "ps", false, "Same as -p.")
+	flags.BoolVar(&cmd.cInclude, "i", false, "Output in C include file style, an unsigned char array named after the input file and its length (default -c 12).")
+	flags.BoolVar(&cmd.cConst, "include-const", false, "With -i, declare the array and its length const.")
+	flags.StringVar(&cmd.cLenType, "include-len-type", cIncludeLenTypes[0], "With -i, the C type of the length variable: "+strings.Join(cIncludeLenTypes, ", ")+".")
+	flags.BoolVar(&cmd.autoskip, "a", false, "Autoskip: replace runs of all-zero lines with a single '*' line.")
+	flags.BoolVar(&cmd.squeeze, "squeeze", false, "Like -a, but collapse runs of any identical lines (e.g. 0xff padding), not just zero ones.")
+	flags.IntVar(&cmd.skipAfter, "skip-after", 1, "With -a or --squeeze, print the first <n> lines of a run before collapsing the rest.")
+	flags.IntVar(&cmd.maxSkips, "max-skips", 0, "With -a or --squeeze, collapse at most <n> runs and print every line after that (0 for no limit).")
+	flags.StringVar(&cmd.skipMarker, "skip-marker", "*", "With -a or --squeeze, print <s> instead of '*' for the skipped lines. With -r, read <s> lines back as such.")
+	flags.BoolVar(&cmd.revert, "r", false, "Convert a hex dump back into binary (reverse operation).")
+	outputName := flags.String("output", "", "Write the dump (or with -r the binary) to <file> instead of stdout, creating or truncating it.")
+	flags.StringVar(&cmd.patchFile, "patch", "", "With -r, patch <file> in place: each line's bytes are written at its offset, other bytes are kept.")
+	revertSeekStr := flags.String("seek", "", "With -r, shift the output by <n> bytes, e.g. to patch at a base address. Takes the same suffixes as -l.")
+	flags.BoolVar(&cmd.seekZeroFill, "seek-zero-fill", false, "With -seek, write <n> zero bytes when the output can't seek, like a pipe.")
+	flags.BoolVar(&cmd.tolerant, "tolerant", false, "With -r, skip lines that fail to decode (with a warning on stderr) and zero fill their bytes.")
+	flags.BoolVar(&cmd.check, "check", false, "With -r, validate every line's hex and offset order without writing anything.")
+	flags.IntVar(&cmd.groupSize, "g", defaultGroupSize, "Group hex output every <bytes> bytes, separated by a space")
+	word := flags.Int("word", 0, "Group hex output in <8|16|32|64> bit words, the same as -g 1, 2, 4 or 8. With -e the words show in native little-endian order.")
+	flags.IntVar(&cmd.bytesPerLine, "c", defaultCols, "Number of bytes to display per line in the hex dump, 0 puts the whole dump on one line")
+	lenStr := flags.String("l", "", "Limit output to <len> bytes and then stop (default: dump entire input). Sizes take k, M, G or KiB, MiB, GiB suffixes.")
+	flags.StringVar(lenStr, "len", "", "Same as -l.")
+	flags.Int64Var(&cmd.displayOffset, "o", 0, "Add <off> to the displayed file position, may be negative.")
+	seekStr := flags.String("s", "0", "Start dumping at <seek>: a bare or +<seek> offset counts from the start, -<seek> from the end of the input. Takes the same suffixes as -l.")
+	flags.IntVar(&cmd.maxWidth, "max-width-auto", 0, "Shrink bytes per line so every output line fits within <width> characters (0 disables).")
+	flags.BoolVar(&cmd.percent, "percent", false, "Show each line's offset as a percentage of the total size (only when size is known).")
+	flags.BoolVar(&cmd.timestamps, "timestamps", false, "Prefix each line with the wall-clock time (ms precision) its data was read, useful for live streams.")
+	flags.BoolVar(&cmd.follow, "follow", false, "Keep dumping as the input grows, like tail -f, until interrupted.")
+	flags.DurationVar(&cmd.pollInterval, "poll-interval", defaultPollInterval, "With --follow, how long to wait before checking for new data, e.g. 100ms. Shorter is more responsive, longer uses less CPU.")
+	flags.IntVar(&cmd.splitLines, "split-output", 0, "Write the dump across multiple files, each holding at most <n> lines (0 disables).")
+	flags.StringVar(&cmd.splitPrefix, "split-prefix", "out", "File name prefix used by --split-output, files are named <prefix>.000, <prefix>.001, ...")
+	flags.BoolVar(&cmd.html, "html", false, "Output an HTML table with offset, hex and ascii columns.")
+	flags.BoolVar(&cmd.json, "j", false, "Output a JSON array of {\"offset\", \"hex\", \"ascii\"} objects, one per line.")
+	flags.BoolVar(&cmd.json, "json", false, "Same as -j.")
+	flags.BoolVar(&cmd.od, "od", false, "Mimic the output of od -A x -t x1z, for cross-checking against od. Repeated lines collapse into '*' as in od. With -r, read such a dump back.")
+	flags.BoolVar(&cmd.odVerbose, "od-verbose", false, "With --od, print repeated lines instead of collapsing them, like od -v.")
+	flags.BoolVar(&cmd.csv, "csv", false, "Output CSV rows of offset, one column per byte (-c columns) and ascii, with a header line.")
+	flags.BoolVar(&cmd.csvDecimal, "csv-decimal", false, "Use decimal instead of hex for the --csv offset and byte columns.")
+	flags.IntVar(&cmd.asciiWidth, "ascii-width", 0, "Show only the first <n> characters of each line in the ASCII panel (0 shows all).")
+	flags.BoolVar(&cmd.rtl, "rtl", false, "Print hex groups in reverse order on each line (last group first), keeping byte order within groups.")
+	flags.StringVar(&cmd.template, "template", "", "Render each line from a template with {offset}, {hex}, {ascii} and {len} placeholders.")
+	flags.BoolVar(&cmd.stable, "stable", false, "Diff-friendly output with one byte per line, so a changed byte changes exactly one line.")
+	flags.IntVar(&cmd.units, "units", 8, "Display 8-bit bytes or 16-bit code units (16), with -e for little-endian units and a UTF-16 text panel.")
+	flags.IntVar(&cmd.groupSpaces, "group-spaces", 1, "Number of spaces printed between hex groups.")
+	flags.BoolVar(&cmd.bothEndian, "both-endian", false, "Print a big-endian and a little-endian hex panel side by side before the ASCII.")
+	flags.BoolVar(&cmd.trimTrailing, "trim-trailing", false, "Strip the trailing padding from output lines, keeping internal alignment. Space bytes in the ascii panel are kept.")
+	flags.BoolVar(&cmd.trimPadding, "trim", false, "--trim-trailing, and a short last line also drops its hex padding, so its ascii follows the hex after the usual gap.")
+	flags.BoolVar(&cmd.parity, "parity", false, "Append the XOR parity byte of each line's bytes. With -r, verify and strip it.")
+	flags.BoolVar(&cmd.endOffsetCol, "end-offset-col", false, "Append the offset of the last byte on each line after the ASCII panel.")
+	flags.BoolVar(&cmd.leASCII, "le-ascii", false, "With -e, reverse the ASCII panel within each group so it matches the little-endian hex.")
+	flags.BoolVar(&cmd.noASCII, "no-ascii", false, "Leave out the ascii panel, lines end after the hex field.")
+	flags.BoolVar(&cmd.showHexASCII, "show-hex-ascii", false, "List each line as its offset and text, with non-printable bytes as <NN> hex instead of '.'. Widths vary, so there is no hex column.")
+	flags.BoolVar(&cmd.revcomp, "revcomp", false, "Show the reverse complement of each line (A<->T, C<->G) in the ASCII panel, for nucleotide data.")
+	flags.IntVar(&cmd.groupLines, "group-lines", 0, "Insert a blank line after every <n> output lines (0 disables).")
+	flags.IntVar(&cmd.minLines, "min-lines", 0, "Emit at least <n> lines, padding short dumps with lines of placeholders (-ph). -r reads them back with --pad-char.")
+	rangesFile := flags.String("ranges", "", "Dump each <start>:<len> range listed (one per line) in <file>, separated by \"--\". Needs a seekable input.")
+	teeName := flags.String("tee", "", "Copy the raw input bytes to <file> while dumping them, only the bytes -s and -l select.")
+	dumpLines := flags.String("dump-lines", "", "With -r, only revert lines <first>-<last> (1-based, inclusive) of the dump.")
+	padChar := flags.String("pad-char", "", "With -r, treat <char> in the hex field as a placeholder for missing bytes on padded lines.")
+	prependHex := flags.String("prepend-hex", "", "With -r, write the <hex> bytes (e.g. a magic number or BOM) before the reverted content.")
+	flags.BoolVar(&cmd.selfDescribe, "self-describe", false, "Start the dump with a \"# ccxxd cols=.. group=.. endian=..\" header line that -r uses to configure itself.")
+	markerStr := flags.String("offset-from-marker", "", "Show offsets relative to the first occurrence of the byte <0xNN>, negative before it. Needs a seekable input.")
+	flags.BoolVar(&cmd.stats, "stats", false, "Print a summary of the bytes read (total, printable, non-printable and zero bytes) instead of a dump. Respects -s, -l and --charset.")
+	findStr := flags.String("find", "", "Print only the offsets (one per line) where the byte <0xNN> occurs instead of a dump.")
+	flags.BoolVar(&cmd.bitReverse, "bit-reverse", false, "Reverse the bit order of every byte (MSB<->LSB) before displaying it, for LSB-first protocols. Applied before --xor-key and --mask.")
+	maskStr := flags.String("mask", "", "AND every byte with the bit mask <0xNN> before displaying it.")
+	xorKeyHex := flags.String("xor-key", "", "XOR every byte with the repeating <hex> key, e.g. 5a or deadbeef. Also applies to -r.")
+	flags.StringVar(&cmd.symDiffFile, "sym-diff", "", "Read the input as an xxd dump and show the lines where it differs from the dump in <file>.")
+	flags.StringVar(&cmd.hashName, "hash", hashNames[0], "After the dump, print a \"# <algo>: <hex>\" line with the digest of the dumped bytes (respecting -s/-l): "+strings.Join(hashNames, ", ")+".")
+	compareChecksums := flags.Bool("compare-checksums", false, "Print a sha256 checksum of the dumped range (respecting -s/-l) for each file argument instead of dumping.")
+	showXattrs := flags.Bool("xattr", false, "List the input file's extended attributes, with hex dumped values, before the dump (linux only).")
+	structSpec := flags.String("struct", "", "Dump one record per line and decode its fields below it. <spec> is [<name>:]<type>,... with types u8, u16le, u16be, u32le, u32be, u64le, u64be and bytes:<n>.")
+	annotateSpec := flags.String("annotate", "", "Name byte ranges as <start>:<len>=<name>,... and list the fields present below each line.")
+
+	err = flags.Parse(arguments)
+	if errors.Is(err, flag.ErrHelp) {
+		return cmd, err
+	}
+	if err != nil {
+		return cmd, errBadFlags
+	}
+	args := flags.Args()
+
+	// Like xxd, -b and -p have their own defaults for -c and -g
+	setFlags := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	err = cmd.validateFlags(setFlags)
+	if err != nil {
+		return cmd, err
+	}
+
+	// Like od, --od collapses repeated lines unless told not to
+	if cmd.od && !cmd.odVerbose && !cmd.revert {
+		cmd.squeeze = true
+	}
+
+	if setFlags["seek"] {
+		cmd.revertSeek, err = parseSize(*revertSeekStr)
+		if err != nil {
+			return cmd, fmt.Errorf("invalid -seek value: %v", err)
+		}
+	}
+
+	cmd.maxBytes = -1
+	// maxBytes stays -1 (the whole input) unless -l is given, an explicit -l must be a valid size
+	if setFlags["l"] || setFlags["len"] {
+		cmd.maxBytes, err = parseSize(*lenStr)
+		if err != nil {
+			return cmd, fmt.Errorf("invalid -l value: %v", err)
+		}
+	}
+
+	if *compareChecksums {
+		if len(args) == 0 {
+			return cmd, fmt.Errorf("--compare-checksums needs at least one file argument")
+		}
+		cmd.checksumFiles = args
+		// Files differ in size, so only offsets from the start apply to all of them
+		cmd.startOffset, err = resolveSeek(*seekStr, nil)
+		if err != nil {
+			return cmd, err
+		}
+		if *outputName != "" {
+			err = cmd.openOutput(*outputName, args)
+			if err != nil {
+				return cmd, &ioError{err}
+			}
+		}
+		return cmd, nil
+	}
+
+	switch len(args) {
+	case 0:
+		cmd.input = stdin
+	case 1:
+		file, err := os.Open(args[0])
+		if err != nil {
+			return cmd, &ioError{fmt.Errorf("error opening %v as file: %v", args[0], err)}
+		}
+		cmd.input = file
+		cmd.inputName = args[0]
+	default:
+		// Several files are dumped as one stream
+		cmd.input, err = openMultiFile(args)
+		if err != nil {
+			return cmd, &ioError{err}
+		}
+	}
+
+	cmd.startOffset, err = resolveSeek(*seekStr, cmd.input)
+	if err != nil {
+		return cmd, err
+	}
+
+	if *showXattrs {
+		if len(args) != 1 {
+			return cmd, fmt.Errorf("--xattr needs a file argument")
+		}
+		cmd.xattrs, err = readXattrs(args[0])
+		if err != nil {
+			return cmd, &ioError{err}
+		}
+	}
+
+	if *teeName != "" {
+		err = cmd.teeInput(*teeName)
+		if err != nil {
+			return cmd, &ioError{err}
+		}
+	}
+
+	if cmd.maxSkips < 0 {
+		return cmd, fmt.Errorf("--max-skips must be 0 or more, got %d", cmd.maxSkips)
+	}
+
+	if cmd.groupSpaces < 1 {
+		return cmd, fmt.Errorf("--group-spaces must be at least 1, got %d", cmd.groupSpaces)
+	}
+
+	if setFlags["word"] {
+		size, ok := wordGroupSizes[*word]
+		if !ok {
+			return cmd, fmt.Errorf("--word must be 8, 16, 32 or 64, got %d", *word)
+		}
+		if setFlags["g"] && cmd.groupSize != size {
+			return cmd, fmt.Errorf("-g %d and --word %d ask for different group sizes, pick one", cmd.groupSize, *word)
+		}
+		cmd.groupSize = size
+	}
+
+	if cmd.binary {
+		if !setFlags["c"] {
+			cmd.bytesPerLine = defaultColsBinary
+		}
+		if !setFlags["g"] && !setFlags["word"] {
+			cmd.groupSize = defaultGroupSizeBinary
+		}
+	}
+
+	// Only the default grouping becomes 4 with -e, as in xxd an explicit -g 2 is kept
+	if cmd.littleEndian && !setFlags["g"] && !setFlags["word"] {
+		cmd.groupSize = defaultGroupSizeLittleEndian
+	}
+
+	if cmd.plain && !setFlags["c"] {
+		cmd.bytesPerLine = defaultColsPlain
+	}
+
+	if cmd.cInclude && !setFlags["c"] {
+		cmd.bytesPerLine = defaultColsCInclude
+	}
+
+	if *ebcdic {
+		if setFlags["charset"] && *charsetName != "ebcdic" {
+			return cmd, fmt.Errorf("-E and --charset %v ask for different panels, pick one", *charsetName)
+		}
+		*charsetName = "ebcdic"
+	}
+	charset, ok := charsets[*charsetName]
+	if !ok {
+		return cmd, fmt.Errorf("invalid --charset %q, want one of: %v", *charsetName, strings.Join(charsetNames, ", "))
+	}
+	cmd.charset = charset
+
+	if cmd.hashName != "none" {
+		newHash, ok := hashes[cmd.hashName]
+		if !ok {
+			return cmd, fmt.Errorf("invalid --hash %q, want one of: %v", cmd.hashName, strings.Join(hashNames, ", "))
+		}
+		cmd.hash = newHash()
+	}
+
+	if *glyphsSpec != "" {
+		cmd.glyphs, err = parseGlyphs(*glyphsSpec)
+		if err != nil {
+			return cmd, err
+		}
+	}
+
+	if !slices.Contains(cIncludeLenTypes, cmd.cLenType) {
+		return cmd, fmt.Errorf("invalid --include-len-type %q, want one of: %v", cmd.cLenType, strings.Join(cIncludeLenTypes, ", "))
+	}
+
+	// --trim is --trim-trailing plus the short line handling in printHexPadding
+	if cmd.trimPadding {
+		cmd.trimTrailing = true
+	}
+
+	if cmd.skipAfter < 1 {
+		return cmd, fmt.Errorf("--skip-after must be at least 1, got %d", cmd.skipAfter)
+	}
+	if cmd.skipMarker == "" || strings.ContainsAny(cmd.skipMarker, "\r\n") {
+		return cmd, fmt.Errorf("--skip-marker must be a non-empty single line, got %q", cmd.skipMarker)
+	}
+
+	if cmd.pollInterval <= 0 {
+		return cmd, fmt.Errorf("--poll-interval must be positive, got %v", cmd.pollInterval)
+	}
+
+	if cmd.units != 8 && cmd.units != 16 {
+		return cmd, fmt.Errorf("--units must be 8 or 16, got %d", cmd.units)
+	}
+
+	if *rangesFile != "" {
+		cmd.ranges, err = loadRanges(*rangesFile)
+		if err != nil {
+			return cmd, &ioError{err}
+		}
+	}
+
+	if *dumpLines != "" {
+		cmd.dumpFirstLine, cmd.dumpLastLine, err = parseLineRange(*dumpLines)
+		if err != nil {
+			return cmd, err
+		}
+	}
+
+	if *prependHex != "" {
+		cmd.prependBytes, err = hex.DecodeString(*prependHex)
+		if err != nil {
+			return cmd, fmt.Errorf("invalid --prepend-hex value %q: %v", *prependHex, err)
+		}
+	}
+
+	cmd.placeholder, err = parsePlaceholder(*placeholder)
+	if err != nil {
+		return cmd, err
+	}
+
+	if *padChar != "" {
+		cmd.padChar, err = parsePadChar(*padChar)
+		if err != nil {
+			return cmd, err
+		}
+	}
+
+	if *maskStr != "" {
+		cmd.mask, err = parseMask(*maskStr)
+		if err != nil {
+			return cmd, err
+		}
+		cmd.useMask = true
+	}
+
+	if *markerStr != "" {
+		value, err := strconv.ParseUint(*markerStr, 0, 8)
+		if err != nil {
+			return cmd, fmt.Errorf("invalid --offset-from-marker value %q, want a byte value like 0x7e", *markerStr)
+		}
+		cmd.marker = byte(value)
+		cmd.useMarker = true
+	}
+
+	if *findStr != "" {
+		value, err := strconv.ParseUint(*findStr, 0, 8)
+		if err != nil {
+			return cmd, fmt.Errorf("invalid --find value %q, want a byte value like 0x0a", *findStr)
+		}
+		cmd.findByte = byte(value)
+		cmd.useFind = true
+	}
+
+	if *xorKeyHex != "" {
+		cmd.xorKey, err = parseXorKey(*xorKeyHex)
+		if err != nil {
+			return cmd, err
+		}
+	}
+
+	if *annotateSpec != "" {
+		cmd.annotations, err = parseAnnotations(*annotateSpec)
+		if err != nil {
+			return cmd, err
+		}
+	}
+
+	// Each record gets a line of its own
+	if *structSpec != "" {
+		cmd.structFields, err = parseStruct(*structSpec)
+		if err != nil {
+			return cmd, err
+		}
+		cmd.bytesPerLine = structSize(cmd.structFields)
+	}
+
+	// -c 0 puts the whole dump on a single line
+	if cmd.bytesPerLine <= 0 && cmd.follow {
+		return cmd, fmt.Errorf("-c 0 needs the whole input to lay out its line, it can't be combined with --follow")
+	}
+	if cmd.bytesPerLine <= 0 && !cmd.revert {
+		cmd.bytesPerLine, err = cmd.singleLineColumns()
+		if err != nil {
+			return cmd, err
+		}
+	}
+
+	// Validate and fix up byte grouping as needed
+	cmd.groupSize, err = validateByteGrouping(cmd.groupSize, cmd.bytesPerLine, cmd.littleEndian)
+	if err != nil {
+		return cmd, err
+	}
+
+	// Shrink columns to fit the requested output width
+	if cmd.maxWidth > 0 {
+		cmd.bytesPerLine, err = cmd.fitColumns(cmd.maxWidth)
+		if err != nil {
+			return cmd, err
+		}
+		cmd.groupSize = min(cmd.groupSize, cmd.bytesPerLine)
+	}
+
+	// Opened last, so a file isn't truncated for a command line that fails to load
+	if *outputName != "" {
+		err = cmd.openOutput(*outputName, args)
+		if err != nil {
+			return cmd, &ioError{err}
+		}
+	}
+
+	return cmd, nil
+}
+
+// validateFlags rejects flag combinations where one flag would silently be ignored
+// or make no sense with the other. setFlags holds the flags given on the command line.
+func (cmd *command) validateFlags(setFlags map[string]bool) error {
+	switch {
+	case cmd.binary && cmd.littleEndian:
+		return fmt.Errorf("-b prints bits in file order, it can't be combined with -e")
+	case cmd.binary && cmd.revert:
+		return fmt.Errorf("-r can't read -b bit dumps back")
+	case cmd.binary && cmd.plain:
+		return fmt.Errorf("-p prints plain hex, it can't be combined with -b")
+	case cmd.binary && cmd.cInclude:
+		return fmt.Errorf("-i prints hex bytes, it can't be combined with -b")
+	case cmd.cInclude && cmd.revert:
+		return fmt.Errorf("-r can't read -i C include output back")
+	case cmd.cInclude && cmd.plain:
+		return fmt.Errorf("-i and -p are different output formats, pick one")
+	case cmd.littleEndian && cmd.plain:
+		return fmt.Errorf("-p prints bytes in file order, it can't be combined with -e")
+	case cmd.littleEndian && cmd.cInclude:
+		return fmt.Errorf("-i prints bytes in file order, it can't be combined with -e")
+	case cmd.bothEndian && cmd.littleEndian:
+		return fmt.Errorf("--both-endian already shows little-endian order, drop -e")
+	case cmd.revert && (setFlags["l"] || setFlags["len"]):
+		return fmt.Errorf("-l limits a dump, it has no effect with -r")
+	case cmd.revert && setFlags["s"]:
+		return fmt.Errorf("-s picks where a dump starts, use -seek to shift the output of -r")
+	case cmd.json && cmd.revert:
+		return fmt.Errorf("-r can't read --json dumps back")
+	case cmd.json && (cmd.autoskip || cmd.squeeze):
+		return fmt.Errorf("-a and --squeeze would put \"*\" lines into the --json array")
+	case cmd.json && setFlags["ranges"]:
+		return fmt.Errorf("--ranges would print one --json array per range, with \"--\" lines between them")
+	case cmd.patchFile != "" && setFlags["output"]:
+		return fmt.Errorf("--patch writes to its own file, drop --output")
+	case cmd.patchFile != "" && (cmd.tolerant || setFlags["dump-lines"] || setFlags["prepend-hex"]):
+		return fmt.Errorf("--patch writes each line at its offset, it can't be combined with --tolerant, --dump-lines or --prepend-hex")
+	case cmd.rtl && cmd.littleEndian:
+		return fmt.Errorf("--rtl reverses big-endian groups, it can't be combined with -e")
+	case cmd.template != "" && cmd.binary:
+		return fmt.Errorf("--template prints hex, it can't be combined with -b")
+	case cmd.template != "" && (cmd.percent || cmd.timestamps):
+		return fmt.Errorf("--template lays out the whole line, it can't be combined with --percent or --timestamps")
+	case cmd.stable && (cmd.autoskip || cmd.squeeze):
+		return fmt.Errorf("--stable prints every byte on its own line, it can't be combined with -a or --squeeze")
+	case cmd.csv && setFlags["ranges"]:
+		return fmt.Errorf("--ranges would put \"--\" lines between the --csv rows")
+	case cmd.splitLines > 0 && setFlags["output"]:
+		return fmt.Errorf("--split-output writes its own files, drop --output")
+	case !cmd.revert && cmd.patchFile != "":
+		return fmt.Errorf("--patch only works with -r")
+	case !cmd.revert && setFlags["seek"]:
+		return fmt.Errorf("-seek only works with -r, use -s to start a dump later")
+	case !cmd.revert && (cmd.tolerant || cmd.check):
+		return fmt.Errorf("--tolerant and --check only work with -r")
+	case !cmd.autoskip && !cmd.squeeze && (setFlags["skip-after"] || setFlags["max-skips"]):
+		return fmt.Errorf("--skip-after and --max-skips only work with -a or --squeeze")
+	case !cmd.autoskip && !cmd.squeeze && !cmd.revert && setFlags["skip-marker"]:
+		return fmt.Errorf("--skip-marker only works with -a, --squeeze or -r")
+	case cmd.showHexASCII && (cmd.revert || cmd.plain || cmd.cInclude || cmd.csv || cmd.html || cmd.json || cmd.od || cmd.stable):
+		return fmt.Errorf("--show-hex-ascii is an output format of its own, it can't be combined with -r, -p, -i, --csv, --html, --json, --od or --stable")
+	case cmd.units == 16 && cmd.bytesPerLine%2 != 0:
+		return fmt.Errorf("--units 16 needs an even -c, got %d", cmd.bytesPerLine)
+	case cmd.units == 16 && setFlags["g"]:
+		return fmt.Errorf("--units 16 groups the hex by code unit, it can't be combined with -g")
+	case cmd.od && !cmd.revert && (cmd.plain || cmd.cInclude || cmd.csv || cmd.html || cmd.json || cmd.stable || setFlags["find"]):
+		return fmt.Errorf("--od is an output format of its own, it can't be combined with -p, -i, --csv, --html, --json, --stable or --find")
+	case !cmd.od && cmd.odVerbose:
+		return fmt.Errorf("--od-verbose only works with --od")
+	case !cmd.follow && setFlags["poll-interval"]:
+		return fmt.Errorf("--poll-interval only works with --follow")
+	case cmd.follow && (cmd.revert || cmd.cInclude || cmd.stats || setFlags["ranges"] || setFlags["compare-checksums"] || setFlags["sym-diff"]):
+		return fmt.Errorf("--follow keeps a dump going, it can't be combined with -r, -i, --stats, --ranges, --compare-checksums or --sym-diff")
+	}
+	if cmd.hashName != "" && cmd.hashName != "none" {
+		for _, name := range hashConflicts {
+			if setFlags[name] {
+				return fmt.Errorf("--hash ends a plain dump with a digest line, it can't be combined with %v", flagName(name))
+			}
+		}
+	}
+	if cmd.stats {
+		for _, name := range statsConflicts {
+			if setFlags[name] {
+				return fmt.Errorf("--stats prints a summary instead of a dump, it can't be combined with %v", flagName(name))
+			}
+		}
+	}
+	return nil
+}
+
+// flagName returns a flag the way it is written in messages: single letter flags
+// and xxd's -ps with one dash, long names with two.
+func flagName(name string) string {
+	if len(name) == 1 || name == "ps" {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// singleLineColumns returns the number of bytes the dump will cover, the -c 0 line length.
+// Input of unknown length needs -l, since the line has to be laid out before reading it.
+func (cmd *command) singleLineColumns() (int, error) {
+	end, err := getEndByte(cmd.maxBytes, cmd.startOffset, cmd.input)
+	if err != nil {
+		return 0, err
+	}
+	if end == unknownLength {
+		return 0, fmt.Errorf("-c 0 needs an input of known length, or -l")
+	}
+	return int(max(end-cmd.startOffset, 1)), nil
+}
+
+// closeInput closes the input file(s) opened from the arguments, stdin is left open,
+// and the --tee file. Only closing the --tee file can fail, since it was written to.
+func (cmd *command) closeInput() error {
+	if closer, ok := cmd.input.(io.Closer); ok && cmd.input != os.Stdin {
+		closer.Close()
+	}
+	if cmd.teeFile == nil {
+		return nil
+	}
+	return cmd.teeFile.Close()
+}
+
+// openOutput points cmd.output at the named file, created or truncated.
+// Refuses to truncate one of the input files, which would be lost before it is read.
+// The caller closes it with closeOutput.
+func (cmd *command) openOutput(name string, inputs []string) error {
+	if info, err := os.Stat(name); err == nil {
+		for _, input := range inputs {
+			inputInfo, err := os.Stat(input)
+			if err == nil && os.SameFile(info, inputInfo) {
+				return fmt.Errorf("output file %v is also an input", name)
+			}
+		}
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("error creating output file %v: %v", name, err)
+	}
+	cmd.outputFile = file
+	cmd.output = file
+	return nil
+}
+
+// closeOutput closes the --output file, if any.
+func (cmd *command) closeOutput() error {
+	if cmd.outputFile == nil {
+		return nil
+	}
+	return cmd.outputFile.Close()
+}
+
+// teeInput creates the named file that teeBytes copies the dumped input to.
+// cmd.input is left as it is, so it can still be seeked and sized.
+// The caller closes cmd.teeFile with closeInput.
+func (cmd *command) teeInput(name string) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("error creating tee file %v: %v", name, err)
+	}
+	cmd.teeFile = file
+	return nil
+}
+
+// teeBytes copies input bytes that are being dumped to the --tee file, if any.
+func (cmd *command) teeBytes(data []byte) error {
+	if cmd.teeFile == nil {
+		return nil
+	}
+	_, err := cmd.teeFile.Write(data)
+	if err != nil {
+		return fmt.Errorf("error writing tee file: %v", err)
+	}
+	return nil
+}
+
+// revertOptions collects the flags that affect -r
+func (cmd *command) revertOptions() revertOptions {
+	return revertOptions{
+		csv:          cmd.csv,
+		csvDecimal:   cmd.csvDecimal,
+		od:           cmd.od,
+		xorKey:       cmd.xorKey,
+		prefix:       cmd.prependBytes,
+		parity:       cmd.parity,
+		padChar:      cmd.padChar,
+		decimal:      cmd.decimal,
+		seek:         cmd.revertSeek,
+		zeroFill:     cmd.seekZeroFill,
+		littleEndian: cmd.littleEndian,
+		groupSize:    cmd.groupSize,
+		tolerant:     cmd.tolerant,
+		warnings:     cmd.errOutput,
+		firstLine:    cmd.dumpFirstLine,
+		lastLine:     cmd.dumpLastLine,
+		skipMarker:   cmd.skipMarker,
+	}
+}
+
+// patch applies the dump read from cmd.input to cmd.patchFile, creating the file if needed.
+func (cmd *command) patch() error {
+	file, err := os.OpenFile(cmd.patchFile, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	err = patchBinary(cmd.input, file, cmd.revertOptions())
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// run dumps the whole input, see runContext.
+func (cmd *command) run() error {
+	return cmd.runContext(context.Background())
+}
+
+// skipToOffset moves input to offset before a dump reads it through reader. Seekable
+// input seeks there, others (a pipe is an *os.File too, its Seek fails) have the
+// bytes before offset read from reader and dropped.
+func skipToOffset(input, reader io.Reader, offset int64) error {
+	if offset <= 0 {
+		return nil
+	}
+	if seeker, ok := input.(io.Seeker); ok {
+		_, err := seeker.Seek(offset, io.SeekStart)
+		if err == nil {
+			return nil
+		}
+	}
+	_, err := io.CopyN(io.Discard, reader, offset)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("error skipping to offset: %v", err)
+	}
+	return nil
+}
+
+// flushOutput writes out what the dump has buffered so far.
+func (cmd *command) flushOutput() error {
+	if flusher, ok := cmd.output.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// liveInput reports whether input may still be arriving while it is dumped,
+// as anything but a regular file can.
+func liveInput(input io.Reader) bool {
+	file, ok := input.(*os.File)
+	if !ok {
+		return true
+	}
+	info, err := file.Stat()
+	return err != nil || !info.Mode().IsRegular()
+}
+
+// Main hex dump loop: reads bytes, formats, and prints each line
+// Once ctx is done the dump stops before the next line, keeping what was already written.
+func (cmd *command) runContext(ctx context.Context) (err error) {
+	if cmd.bytesPerLine <= 0 {
+		return fmt.Errorf("bytes per line must be positive, got %d", cmd.bytesPerLine)
+	}
+	// Library callers fill in the struct themselves, a group of 0 would divide by zero
+	if cmd.groupSize <= 0 {
+		cmd.groupSize = cmd.bytesPerLine
+	}
+
+	// determine where reading should end
+	cmd.endOffset, err = getEndByte(cmd.maxBytes, cmd.startOffset, cmd.input)
+	if err != nil {
+		return err
+	}
+
+	// A followed input grows, its current size isn't where the dump ends
+	if cmd.follow {
+		cmd.endOffset = unknownLength
+		if cmd.maxBytes >= 0 {
+			cmd.endOffset = cmd.startOffset + cmd.maxBytes
+		}
+	}
+
+	// Percentages only make sense when we know where the dump ends
+	if cmd.endOffset == unknownLength {
+		cmd.percent = false
+	}
+
+	cmd.offsetWidth = cmd.offsetDigits()
+
+	if cmd.littleEndian {
+		// One space after the last group's separator, as in printHexPadding
+		cmd.wantedHexWidth = cmd.offsetWidth + len(": ") + littleEndianHexWidth(cmd.bytesPerLine, cmd.groupSize, cmd.groupSpacing()) + 1
+		cmd.wantedHexWidth += cmd.extraColumnsWidth()
+	}
+
+	if cmd.useMarker {
+		cmd.markerOffset, err = cmd.findMarker()
+		if err != nil {
+			return err
+		}
+	}
+
+	if cmd.timestamps && cmd.clock == nil {
+		cmd.clock = time.Now
+	}
+
+	// Rotate through numbered output files, closing the last one when done
+	if cmd.splitLines > 0 {
+		split := &splitWriter{prefix: cmd.splitPrefix, maxLines: cmd.splitLines}
+		cmd.output = split
+		defer func() {
+			closeErr := split.Close()
+			if err == nil {
+				err = closeErr
+			}
+		}()
+	} else {
+		// One write per line is slow on an unbuffered stdout, so buffer it
+		// and flush once the dump is done or fails. Lines of a live input are
+		// flushed as they are read, see flushLines below.
+		output := cmd.output
+		buffered := bufio.NewWriter(output)
+		cmd.output = buffered
+		defer func() {
+			flushErr := buffered.Flush()
+			cmd.output = output
+			if err == nil && flushErr != nil {
+				err = fmt.Errorf("error writing output: %v", flushErr)
+			}
+		}()
+	}
+
+	if len(cmd.xattrs) > 0 {
+		err = cmd.printXattrs()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = cmd.beginOutput()
+	if err != nil {
+		return err
+	}
+
+	input := cmd.input
+	if cmd.follow {
+		if cmd.after == nil {
+			cmd.after = time.After
+		}
+		input = &followReader{ctx: ctx, reader: cmd.input, interval: cmd.pollInterval, after: cmd.after}
+	}
+	reader := bufio.NewReader(input)
+	err = skipToOffset(cmd.input, reader, cmd.startOffset)
+	if err != nil {
+		return err
+	}
+	offset := cmd.startOffset // Tracks current byte offset for hex display
+	lines := 0                // Number of lines written
+	// A line of a live input (or one with its read time) is due when it is read
+	flushLines := cmd.follow || cmd.timestamps || liveInput(cmd.input)
+
+	var skipper *autoskipper
+	if cmd.autoskip || cmd.squeeze {
+		skipper = newAutoskipper(cmd)
+	}
+
+	// Loop until we've read up to endByte
+	for offset < cmd.endOffset {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("dump stopped at offset 0x%x: %w", offset, err)
+		}
+
+		// Pass in how many bytes were supposed to read
+		// which is the smallest of cols or bytes left until endbytes
+		length := min(int64(cmd.bytesPerLine), cmd.endOffset-offset)
+
+		lineBytes, err := cmd.readLine(reader, int(length))
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			// lineBytes holds what was read before the error, so this is where it happened
+			return fmt.Errorf("read error at offset 0x%x: %w", offset+int64(len(lineBytes)), err)
+		}
+
+		err = cmd.teeBytes(lineBytes)
+		if err != nil {
+			return err
+		}
+		// The digest is of the input, before any transform below
+		if cmd.hash != nil {
+			cmd.hash.Write(lineBytes)
+		}
+		if cmd.bitReverse {
+			reverseBitsBytes(lineBytes)
+		}
+		if len(cmd.xorKey) > 0 {
+			xorBytes(lineBytes, cmd.xorKey, offset-cmd.startOffset)
+		}
+		if cmd.useMask {
+			maskBytes(lineBytes, cmd.mask)
+		}
+
+		if cmd.groupLines > 0 && lines > 0 && lines%cmd.groupLines == 0 {
+			fmt.Fprintln(cmd.output)
+		}
+		if skipper != nil {
+			err = skipper.line(offset, lineBytes)
+		} else {
+			err = cmd.emitLine(offset, lineBytes)
+		}
+		if err != nil {
+			return err
+		}
+		if flushLines {
+			err = cmd.flushOutput()
+			if err != nil {
+				return fmt.Errorf("error writing output: %v", err)
+			}
+		}
+		offset += int64(len(lineBytes))
+		lines++
+	}
+	if skipper != nil {
+		err = skipper.flush(true)
+		if err != nil {
+			return err
+		}
 	}
 
-	flag.BoolVar(&cmd.littleEndian, "e", false, "Print hex output in little-endian order within each group.")
-	flag.BoolVar(&cmd.revert, "r", false, "Convert a hex dump back into binary (reverse operation).")
-	flag.IntVar(&cmd.groupSize, "g", defaultGroupSize, "Group hex output every <bytes> bytes, separated by a space")
-	flag.IntVar(&cmd.bytesPerLine, "c", defaultCols, "Number of bytes to display per line in the hex dump")
-	flag.Int64Var(&cmd.maxBytes, "l", -1, "Limit output to <len> bytes and then stop (default: dump entire input).")
-	flag.Int64Var(&cmd.startOffset, "s", 0, "Skip <seek> bytes from the start before dumping (default 0, i.e., start at beginning).")
-
-	flag.Parse()
-	args := flag.Args()
-
-	switch len(args) {
-	case 0:
-		cmd.input = os.Stdin
-	case 1:
-		cmd.input, err = os.Open(args[0])
+	// Pad short dumps with empty placeholder lines up to --min-lines
+	for ; lines < cmd.minLines; lines++ {
+		err = cmd.writeLine(cmd.startOffset+int64(lines*cmd.bytesPerLine), nil)
 		if err != nil {
-			fmt.Printf("error opening %v as file: %v", args[0], err)
-			os.Exit(1)
+			return err
 		}
-	default:
-		fmt.Fprintf(os.Stderr, "too many args: %v\n", args)
-		flag.Usage()
-		os.Exit(1)
 	}
 
-	// Validate and fix up byte grouping as needed
-	cmd.groupSize, err = validateByteGrouping(cmd.groupSize, cmd.bytesPerLine, cmd.littleEndian)
+	err = cmd.endOutput(offset)
 	if err != nil {
-		return cmd, err
+		return err
+	}
+	if cmd.hash != nil {
+		cmd.printHash()
 	}
+	return nil
+}
 
-	return cmd, nil
+// beginOutput writes whatever the selected output format needs before the first line.
+func (cmd *command) beginOutput() error {
+	switch {
+	case cmd.csv:
+		return cmd.printCSVHeader()
+	case cmd.html:
+		cmd.printHTMLHeader()
+	case cmd.json:
+		return cmd.printJSONHeader()
+	case cmd.selfDescribe && !cmd.od:
+		cmd.printSelfDescribeHeader()
+	}
+	return nil
 }
 
-// Main hex dump loop: reads bytes, formats, and prints each line
-func (cmd *command) run() error {
-	var err error
-	// determine where reading should end
-	cmd.endOffset, err = getEndByte(cmd.maxBytes, cmd.startOffset, cmd.input)
+// endOutput finishes the selected output format after the last line,
+// offset is where reading stopped.
+func (cmd *command) endOutput(offset int64) error {
+	switch {
+	case cmd.csv:
+		cmd.csvWriter.Flush()
+		return cmd.csvWriter.Error()
+	case cmd.html:
+		cmd.printHTMLFooter()
+	case cmd.json:
+		return cmd.printJSONFooter()
+	case cmd.od:
+		cmd.printODFooter(offset)
+	case cmd.stats:
+		cmd.printStats()
+	}
+	return nil
+}
+
+// emitLine writes a dumped line followed by any --annotate or --struct notes for it.
+func (cmd *command) emitLine(offset int64, line []byte) error {
+	err := cmd.writeLine(offset, line)
 	if err != nil {
 		return err
 	}
-
-	if cmd.littleEndian {
-		cmd.wantedHexWidth = hexFieldWidth(cmd.bytesPerLine, cmd.groupSize)
+	if len(cmd.annotations) > 0 {
+		cmd.printAnnotations(offset, len(line))
 	}
+	if len(cmd.structFields) > 0 {
+		cmd.printStructFields(line)
+	}
+	return nil
+}
 
-	// If input is a file, seek to requested offset
-	if seeker, ok := cmd.input.(io.Seeker); ok && cmd.startOffset > 0 {
-		_, err := seeker.Seek(cmd.startOffset, io.SeekStart)
-		if err != nil {
-			return fmt.Errorf("error setting offset: %v", err)
-		}
+// writeLine prints one line of the dump in the selected output format.
+func (cmd *command) writeLine(offset int64, line []byte) error {
+	// Shown offsets are relative to the marker, 0 unless --offset-from-marker is set
+	offset -= cmd.markerOffset
+	switch {
+	case cmd.stats:
+		cmd.countStats(line)
+	case cmd.useFind:
+		cmd.printFoundOffsets(offset, line)
+	case cmd.plain:
+		cmd.printPlainLine(line)
+	case cmd.csv:
+		return cmd.printCSVLine(offset, line)
+	case cmd.html:
+		cmd.printHTMLLine(offset, line)
+	case cmd.json:
+		return cmd.printJSONLine(offset, line)
+	case cmd.od:
+		cmd.printODLine(offset, line)
+	case cmd.showHexASCII:
+		cmd.printHexASCIILine(offset, line)
+	case cmd.stable:
+		cmd.printStableLines(offset, line)
+	case cmd.units == 16:
+		cmd.printUnits16Line(offset, line)
+	default:
+		cmd.printLine(offset, line)
 	}
+	return nil
+}
 
-	reader := bufio.NewReader(cmd.input)
-	offset := cmd.startOffset // Tracks current byte offset for hex display
+// printPlainLine prints the line's bytes as one run of hex digits, like xxd -p.
+func (cmd *command) printPlainLine(line []byte) {
+	if len(line) == 0 {
+		return
+	}
+	var builder strings.Builder
+	for _, b := range line {
+		cmd.writeHexByte(&builder, b)
+	}
+	fmt.Fprintln(cmd.output, builder.String())
+}
 
-	// Loop until we've read up to endByte
-	for offset < cmd.endOffset {
+// printFoundOffsets prints the offset of every --find byte on the line, one per line.
+func (cmd *command) printFoundOffsets(offset int64, line []byte) {
+	for i, b := range line {
+		if b == cmd.findByte {
+			fmt.Fprintln(cmd.output, cmd.formatOffset(offset+int64(i)))
+		}
+	}
+}
 
-		// Pass in how many bytes were supposed to read
-		// which is the smallest of cols or bytes left until endbytes
-		length := min(int64(cmd.bytesPerLine), cmd.endOffset-offset)
+// printStableLines prints each byte on its own line as "<offset>: <hex>  <char>".
+// The layout never depends on neighbouring bytes, so dumps diff cleanly.
+func (cmd *command) printStableLines(offset int64, line []byte) {
+	var builder strings.Builder
+	for i, b := range line {
+		cmd.writeShownOffset(&builder, offset+int64(i))
+		fmt.Fprintf(&builder, ": "+cmd.hexFormat()+"  ", b)
+		cmd.printASCII([]byte{b}, &builder)
+		builder.WriteString("\n")
+	}
+	fmt.Fprint(cmd.output, builder.String())
+}
 
-		lineBytes, err := cmd.readLine(reader, int(length))
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return err
+// printHexASCIILine prints a line as "<offset>: <text>", with each byte that has no
+// panel character shown as <NN>. The text width varies, so it has a format of its own.
+func (cmd *command) printHexASCIILine(offset int64, line []byte) {
+	var builder strings.Builder
+	cmd.writeShownOffset(&builder, offset)
+	builder.WriteByte(':')
+	if len(line) > 0 {
+		builder.WriteByte(' ')
+	}
+	for _, b := range line {
+		if c, ok := cmd.panelChar(b); ok {
+			builder.WriteRune(c)
+		} else {
+			builder.WriteByte('<')
+			cmd.writeHexByte(&builder, b)
+			builder.WriteByte('>')
 		}
-
-		cmd.printLine(offset, lineBytes)
-		offset += int64(len(lineBytes))
 	}
-	return nil
+	builder.WriteByte('\n')
+	io.WriteString(cmd.output, builder.String())
 }
 
 // readLine: Use io.ReadFull to ensure each line is filled unless at EOF, matching xxd behavior.
@@ -158,40 +1287,315 @@ func (cmd *command) readLine(reader *bufio.Reader, length int) ([]byte, error) {
 		// partial read at last line
 		return buf[:n], nil
 	default:
-		// any other err
-		return nil, err
+		// any other err, with the bytes read before it so the caller can tell where it hit
+		return buf[:n], err
 	}
 }
 
 // Printline builds the whole line in memory with strings.Builder, then writes it once for efficiency.
 func (cmd *command) printLine(offset int64, line []byte) {
+	if cmd.template != "" {
+		cmd.printTemplate(offset, line)
+		return
+	}
+
+	placeholder := line == nil // A --min-lines row past the end of the input
+	if placeholder {
+		// Laid out as a full line, its digits and panel are placeholders
+		line = make([]byte, cmd.bytesPerLine)
+	}
+
 	var builder strings.Builder
+	// One allocation for the usual line, extra columns may still grow it
+	builder.Grow(lineWidth(cmd.bytesPerLine, max(cmd.groupSize, 1), cmd.groupSpacing(), cmd.littleEndian) + 1)
 	lineLength := len(line)
+	if cmd.timestamps {
+		builder.WriteString(cmd.clock().Format(timestampFormat))
+		builder.WriteString(" ")
+	}
 	// Print the offset at the start of the line (8 hex digits)
-	fmt.Fprintf(&builder, "%08x: ", offset)
+	cmd.writeShownOffset(&builder, offset)
+	builder.WriteString(": ")
+	if cmd.percent {
+		// Progress is measured on the real offset, not the marker relative one
+		fmt.Fprintf(&builder, "%3d%% ", (offset+cmd.markerOffset)*100/max(cmd.endOffset, 1))
+	}
 
-	if !cmd.littleEndian {
-		cmd.printHex(line, &builder)
-	} else {
+	hex := &builder
+	var placeholderHex strings.Builder
+	if placeholder {
+		hex = &placeholderHex
+	}
+	switch {
+	case cmd.binary:
+		cmd.printBinary(line, hex)
+	case cmd.bothEndian:
+		cmd.printBothEndianHex(line, hex)
+		lineLength = cmd.bytesPerLine
+	case cmd.rtl && !cmd.littleEndian:
+		cmd.printRTLHex(line, hex)
+		// printRTLHex pads the hex field to full width itself
+		lineLength = cmd.bytesPerLine
+	case !cmd.littleEndian:
+		cmd.printHex(line, hex)
+	default:
 		// needs to return bytecount bcs of left side padding added
-		lineLength = cmd.printLittleEndianHex(line, &builder)
+		lineLength = cmd.printLittleEndianHex(line, hex)
 	}
-	cmd.printHexPadding(lineLength, &builder)
-	cmd.printASCII(line, &builder)
-	fmt.Fprintln(cmd.output, builder.String())
+	if placeholder {
+		builder.WriteString(strings.Map(cmd.placeholderDigit, placeholderHex.String()))
+	}
+	// A placeholder row has no bytes to append columns for
+	trailer := (cmd.endOffsetCol || cmd.parity) && !placeholder
+	panelStart := -1 // Where the ascii panel starts, -1 without one
+	switch {
+	case cmd.noASCII && trailer:
+		// Keeps the appended columns aligned on a short last line
+		cmd.printHexPadding(lineLength, &builder)
+	case cmd.noASCII:
+	case placeholder:
+		cmd.printHexPadding(lineLength, &builder)
+		panelStart = builder.Len()
+		width := len(line)
+		if cmd.asciiWidth > 0 {
+			width = min(width, cmd.asciiWidth)
+		}
+		for range width {
+			builder.WriteByte(cmd.placeholderChar())
+		}
+	default:
+		cmd.printHexPadding(lineLength, &builder)
+		panelStart = builder.Len()
+		cmd.printASCII(line, &builder)
+	}
+	if cmd.endOffsetCol && trailer {
+		cmd.printEndOffset(offset, line, &builder)
+	}
+	if cmd.parity && trailer {
+		fmt.Fprintf(&builder, "  "+cmd.hexFormat(), parityByte(line))
+	}
+	// Only padding and separators can trail when nothing follows them. Spaces in the
+	// panel (or after it) are bytes of the dump, so a written panel is never trimmed.
+	if (cmd.trimTrailing || cmd.noASCII) && (panelStart < 0 || panelStart == builder.Len()) {
+		fmt.Fprintln(cmd.output, strings.TrimRight(builder.String(), " "))
+		return
+	}
+	builder.WriteByte('\n')
+	io.WriteString(cmd.output, builder.String())
+}
+
+// printTemplate renders a line from the --template layout.
+// {offset} is the offset column as printLine writes it, -o and -d included,
+// {hex} is the grouped hex field without padding, {len} the number of bytes on the line.
+func (cmd *command) printTemplate(offset int64, line []byte) {
+	var hexField, ascii strings.Builder
+	if cmd.littleEndian {
+		cmd.printLittleEndianHex(line, &hexField)
+	} else {
+		cmd.printHex(line, &hexField)
+	}
+	cmd.printASCII(line, &ascii)
+
+	replacer := strings.NewReplacer(
+		"{offset}", cmd.formatOffset(offset),
+		"{hex}", strings.TrimSpace(hexField.String()),
+		"{ascii}", ascii.String(),
+		"{len}", strconv.Itoa(len(line)),
+	)
+	fmt.Fprintln(cmd.output, replacer.Replace(cmd.template))
+}
+
+// printEndOffset pads the ASCII panel to full width and appends the offset of the line's last byte.
+func (cmd *command) printEndOffset(offset int64, line []byte, builder *strings.Builder) {
+	panelWidth := cmd.bytesPerLine
+	if cmd.noASCII {
+		panelWidth = 0
+	}
+	if cmd.trimPadding {
+		// --trim already gave up the column alignment of short lines
+		panelWidth = min(panelWidth, len(line))
+	}
+	if cmd.asciiWidth > 0 {
+		panelWidth = min(panelWidth, cmd.asciiWidth)
+	}
+	for i := min(len(line), panelWidth); i < panelWidth; i++ {
+		builder.WriteString(" ")
+	}
+	builder.WriteString("  ")
+	cmd.writeShownOffset(builder, offset+int64(len(line))-1)
+}
+
+// extraColumnsWidth returns the width of optional columns printed around the offset,
+// needed to keep the little endian ASCII panel aligned.
+func (cmd *command) extraColumnsWidth() int {
+	width := 0
+	if cmd.percent {
+		width += percentCharWidth
+	}
+	if cmd.timestamps {
+		width += timestampCharWidth
+	}
+	return width
+}
+
+// hexFormat returns the format verb for one byte as two hex digits, upper case with -u.
+func (cmd *command) hexFormat() string {
+	if cmd.uppercase {
+		return "%02X"
+	}
+	return "%02x"
+}
+
+// Hex digits by value, used to write bytes without going through fmt
+const (
+	lowerHexDigits = "0123456789abcdef"
+	upperHexDigits = "0123456789ABCDEF"
+)
+
+// writeHexByte writes b as two hex digits, like hexFormat but much cheaper per byte.
+func (cmd *command) writeHexByte(builder *strings.Builder, b byte) {
+	digits := lowerHexDigits
+	if cmd.uppercase {
+		digits = upperHexDigits
+	}
+	builder.WriteByte(digits[b>>4])
+	builder.WriteByte(digits[b&0x0f])
+}
+
+// offsetFormat returns the format verb for a cmd.offsetWidth digit hex offset,
+// upper case with -u, or decimal with -d. Offsets too big for it still widen the column.
+func (cmd *command) offsetFormat() string {
+	verb := "x"
+	switch {
+	case cmd.decimal:
+		verb = "d"
+	case cmd.uppercase:
+		verb = "X"
+	}
+	return "%0" + strconv.Itoa(max(cmd.offsetWidth, minOffsetDigits)) + verb
+}
+
+// shownOffset returns a dump offset as the output shows it, shifted by -o.
+func (cmd *command) shownOffset(offset int64) int64 {
+	return offset + cmd.displayOffset
+}
+
+// writeShownOffset writes the offset column for a dump offset, shifted by -o.
+// Every printer with an offset column goes through it.
+func (cmd *command) writeShownOffset(builder *strings.Builder, offset int64) {
+	switch {
+	case cmd.displayOffset != 0:
+		// -o only shifts the shown address, below zero it wraps around as in xxd
+		cmd.writeOffset(builder, uint64(cmd.shownOffset(offset)))
+	case offset < 0:
+		// Before the --offset-from-marker byte, rare enough to leave to fmt
+		fmt.Fprintf(builder, cmd.offsetFormat(), offset)
+	default:
+		cmd.writeOffset(builder, uint64(offset))
+	}
+}
+
+// formatOffset returns the offset column for a dump offset, see writeShownOffset.
+func (cmd *command) formatOffset(offset int64) string {
+	var builder strings.Builder
+	cmd.writeShownOffset(&builder, offset)
+	return builder.String()
+}
+
+// writeOffset writes offset as offsetFormat would, but without going through fmt.
+func (cmd *command) writeOffset(builder *strings.Builder, offset uint64) {
+	var buf [64]byte
+	var digits []byte
+	switch {
+	case cmd.decimal:
+		digits = strconv.AppendUint(buf[:0], offset, 10)
+	case cmd.uppercase:
+		digits = strconv.AppendUint(buf[:0], offset, 16)
+		for i, c := range digits {
+			if c >= 'a' {
+				digits[i] = c - 'a' + 'A'
+			}
+		}
+	default:
+		digits = strconv.AppendUint(buf[:0], offset, 16)
+	}
+	for range max(cmd.offsetWidth, minOffsetDigits) - len(digits) {
+		builder.WriteByte('0')
+	}
+	builder.Write(digits)
+}
+
+// offsetDigits returns how many digits the offset column needs for the last offset
+// of the dump, at least minOffsetDigits. A dump of unknown length starts at the minimum.
+func (cmd *command) offsetDigits() int {
+	if cmd.endOffset == unknownLength || cmd.endOffset <= 0 {
+		return minOffsetDigits
+	}
+	last := uint64(cmd.endOffset - 1 + cmd.displayOffset)
+	base := 16
+	if cmd.decimal {
+		base = 10
+	}
+	return max(len(strconv.FormatUint(last, base)), minOffsetDigits)
+}
+
+// groupSpacing returns the number of spaces between hex groups, 1 unless set by --group-spaces.
+func (cmd *command) groupSpacing() int {
+	return max(cmd.groupSpaces, 1)
+}
+
+// groupSeparator returns the string printed between hex groups.
+func (cmd *command) groupSeparator() string {
+	return strings.Repeat(" ", cmd.groupSpacing())
+}
+
+// groupEnd reports whether byte i of a line is the last of its group. Groups end every
+// groupSize bytes and at the end of a full line, where the last group may be partial.
+// Every group end is followed by a separator, printed or padded, so together with the
+// gap space from printHexPadding the ascii panel starts where xxd puts it.
+func (cmd *command) groupEnd(i int) bool {
+	return (i+1)%cmd.groupSize == 0 || i+1 == cmd.bytesPerLine
 }
 
 // printHex prints normal (big-endian) hex output, grouped as specified.
-// This function prints each byte as two hex digits, inserting a space after every 'byteGrouping' bytes.
+// This function prints each byte as two hex digits, inserting a space after every group.
 func (cmd *command) printHex(line []byte, builder *strings.Builder) {
 	for i, b := range line {
-		fmt.Fprintf(builder, "%02x", b)
-		if (i+1)%cmd.groupSize == 0 {
-			builder.WriteString(" ")
+		cmd.writeHexByte(builder, b)
+		if cmd.groupEnd(i) {
+			builder.WriteString(cmd.groupSeparator())
+		}
+	}
+}
+
+// printBinary prints each byte as eight binary digits, grouped like printHex.
+func (cmd *command) printBinary(line []byte, builder *strings.Builder) {
+	for i, b := range line {
+		fmt.Fprintf(builder, "%08b", b)
+		if cmd.groupEnd(i) {
+			builder.WriteString(cmd.groupSeparator())
 		}
 	}
-	// ensures a double space before ascii if
-	if cmd.bytesPerLine%cmd.groupSize != 0 {
+}
+
+// printRTLHex prints the hex groups of the line in reverse order, group N first and group 0 last.
+// Bytes within a group keep their order. Short lines are padded to the full hex field width
+// so the ASCII panel stays aligned.
+func (cmd *command) printRTLHex(line []byte, builder *strings.Builder) {
+	start := builder.Len()
+	lastGroup := (len(line) - 1) / cmd.groupSize
+
+	for g := lastGroup; g >= 0; g-- {
+		end := min((g+1)*cmd.groupSize, len(line))
+		for _, b := range line[g*cmd.groupSize : end] {
+			cmd.writeHexByte(builder, b)
+		}
+		builder.WriteString(cmd.groupSeparator())
+	}
+
+	// Same width printHex produces for a full line
+	width := bigEndianHexWidth(cmd.bytesPerLine, cmd.groupSize, cmd.groupSpacing())
+	for builder.Len()-start < width {
 		builder.WriteString(" ")
 	}
 }
@@ -218,56 +1622,216 @@ func (cmd *command) printLittleEndianHex(line []byte, builder *strings.Builder)
 		// Print the bytes of this group in reverse order (for little-endian display).
 		if start < len(line) {
 			for j := end - 1; j >= start; j-- {
-				fmt.Fprintf(builder, "%02x", line[j]) // Print byte as two hex digits
+				cmd.writeHexByte(builder, line[j]) // Print byte as two hex digits
 			}
 			// After each group, insert a space to separate groups visually.
-			builder.WriteString(" ")
+			builder.WriteString(cmd.groupSeparator())
 		}
 
 	}
-	builder.WriteString(" ")
 	return length
 }
 
-// Print ASCII representation (print '.' for non-printable)
+// printBothEndianHex prints the big-endian hex panel followed by the little-endian one.
+// Both panels are padded to the wider of the two full line widths so the ASCII stays aligned,
+// the little-endian one is wider when a partial last group gets left padded.
+func (cmd *command) printBothEndianHex(line []byte, builder *strings.Builder) {
+	width := max(bigEndianHexWidth(cmd.bytesPerLine, cmd.groupSize, cmd.groupSpacing()),
+		littleEndianHexWidth(cmd.bytesPerLine, cmd.groupSize, cmd.groupSpacing()))
+
+	var bigEndian, littleEndian strings.Builder
+	cmd.printHex(line, &bigEndian)
+	cmd.printLittleEndianHex(line, &littleEndian)
+
+	for _, panel := range []string{bigEndian.String(), littleEndian.String()} {
+		panel = strings.TrimRight(panel, " ")
+		builder.WriteString(panel)
+		builder.WriteString(strings.Repeat(" ", width-len(panel)))
+	}
+}
+
+// Print ASCII representation (print '.' for non-printable), decoded with --charset
+// With --ascii-width only the first asciiWidth bytes are shown.
 func (cmd *command) printASCII(line []byte, builder *strings.Builder) {
+	if cmd.revcomp {
+		line = reverseComplement(line)
+	}
+	if cmd.leASCII && cmd.littleEndian {
+		line = reverseGroups(line, cmd.groupSize)
+	}
+	if cmd.asciiWidth > 0 {
+		line = line[:min(len(line), cmd.asciiWidth)]
+	}
 	for _, b := range line {
-		if isValidASCII(b) {
-			fmt.Fprintf(builder, "%s", string(b))
+		if c, ok := cmd.panelChar(b); ok {
+			builder.WriteRune(c)
 		} else {
-			fmt.Fprint(builder, ".")
+			builder.WriteByte(cmd.placeholderChar())
 		}
 	}
 }
 
+// panelChar returns the ascii panel character for b per --glyphs and --charset,
+// ok is false if b has none.
+func (cmd *command) panelChar(b byte) (rune, bool) {
+	if glyph, ok := cmd.glyphs[b]; ok {
+		return glyph, true
+	}
+	if cmd.charset == nil {
+		return asciiChar(b)
+	}
+	return cmd.charset(b)
+}
+
+// placeholderChar returns the char shown for non-printable bytes, '.' unless set by -ph.
+func (cmd *command) placeholderChar() byte {
+	if cmd.placeholder == 0 {
+		return '.'
+	}
+	return cmd.placeholder
+}
+
+// placeholderDigit maps a digit of a --min-lines row to the placeholder, keeping the
+// separators so the row lines up with the others. For use with strings.Map.
+func (cmd *command) placeholderDigit(r rune) rune {
+	if r == ' ' {
+		return r
+	}
+	return rune(cmd.placeholderChar())
+}
+
+// parsePlaceholder validates a -ph value, a single printable ascii char.
+func parsePlaceholder(s string) (byte, error) {
+	if len(s) != 1 || !isValidASCII(s[0]) {
+		return 0, fmt.Errorf("invalid -ph %q, want a single printable ascii char", s)
+	}
+	return s[0], nil
+}
+
 // Returns true if b is a printable ASCII character
 func isValidASCII(b byte) bool {
 	return b >= 32 && b <= 126
 }
 
 // Prints extra spaces at end of short lines, so ASCII lines up
+// With --trim a short line only gets the gap a full line ends with.
 func (cmd *command) printHexPadding(bytesRead int, builder *strings.Builder) {
 	builder.WriteString(" ")
 
+	if cmd.trimPadding && bytesRead > 0 {
+		// -e always ends on a group separator, otherwise only at a group boundary
+		if !cmd.littleEndian && !cmd.groupEnd(bytesRead-1) {
+			builder.WriteString(cmd.groupSeparator())
+		}
+		return
+	}
+
 	if cmd.littleEndian {
-		// fmt.Printf("builder len is %v and cmd wanted width is %v\n", builder.Len(), cmd.wantedWidth)
-		// the 12 is the chars for offset printing
+		// Partial groups are already left padded, so only whole missing groups are left to fill
 		for builder.Len() < cmd.wantedHexWidth {
-			// fmt.Printf("builder len is %v and cmd wanted width is %v\n", builder.Len(), cmd.wantedWidth)
 			builder.WriteString(" ")
 		}
 	} else {
-		// For each missing byte, print "  " instead of hex
+		// For each missing byte, print blanks as wide as its hex (or binary) digits
+		blank := "  "
+		if cmd.binary {
+			blank = "        "
+		}
 		for i := bytesRead; i < cmd.bytesPerLine; i++ {
-			builder.WriteString("  ")
+			builder.WriteString(blank)
 			// Add group space if this would have been a group boundary
-			if (i+1)%cmd.groupSize == 0 {
-				builder.WriteString(" ")
+			if cmd.groupEnd(i) {
+				builder.WriteString(cmd.groupSeparator())
 			}
 		}
 	}
 }
 
+// resolveSeek turns a -s value into an absolute start offset. A bare or "+" prefixed
+// number counts from the start of the input, a "-" prefixed one back from its end,
+// which needs a seekable input.
+func resolveSeek(s string, file io.Reader) (int64, error) {
+	offset, err := parseSize(strings.TrimLeft(s, "+-"))
+	if err != nil || len(s)-len(strings.TrimLeft(s, "+-")) > 1 {
+		return 0, fmt.Errorf("invalid -s value %q", s)
+	}
+	if !strings.HasPrefix(s, "-") {
+		return offset, nil
+	}
+	offset = -offset
+
+	// Several files can't seek as one, but their sizes add up
+	if m, ok := file.(*multiFile); ok {
+		size, err := m.size()
+		if err != nil {
+			return 0, err
+		}
+		if size == unknownLength {
+			return 0, fmt.Errorf("-s %v needs regular files", s)
+		}
+		if size+offset < 0 {
+			return 0, fmt.Errorf("-s %v seeks before the start of the %d byte input", s, size)
+		}
+		return size + offset, nil
+	}
+
+	// Pipes are files too, so ask for the size by seeking rather than from Stat
+	seeker, ok := file.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("-s %v needs a seekable input", s)
+	}
+	size, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, fmt.Errorf("-s %v needs a seekable input: %v", s, err)
+	}
+	_, err = seeker.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, fmt.Errorf("error setting offset: %v", err)
+	}
+	if size+offset < 0 {
+		return 0, fmt.Errorf("-s %v seeks before the start of the %d byte input", s, size)
+	}
+	return size + offset, nil
+}
+
+// wordGroupSizes maps the --word bit widths to group sizes in bytes.
+var wordGroupSizes = map[int]int{8: 1, 16: 2, 32: 4, 64: 8}
+
+// sizeUnits are the suffixes parseSize accepts, in lower case and longest first
+// so "kib" is tried before "k".
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30},
+	{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9},
+	{"k", 1e3}, {"m", 1e6}, {"g", 1e9},
+}
+
+// parseSize parses a byte count for -l and -s: a number (0x and 0 prefixes work as
+// in strconv.ParseInt) with an optional case insensitive unit, decimal k, M, G
+// (also kB, MB, GB) or binary KiB, MiB, GiB.
+func parseSize(s string) (int64, error) {
+	number, multiplier := s, int64(1)
+	for _, unit := range sizeUnits {
+		if len(s) > len(unit.suffix) && strings.EqualFold(s[len(s)-len(unit.suffix):], unit.suffix) {
+			number, multiplier = s[:len(s)-len(unit.suffix)], unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("size %q is negative", s)
+	}
+	if n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return n * multiplier, nil
+}
+
 // Returns the end byte offset for the dump (either file size or user-specified length)
 func getEndByte(maxBytes, startOffset int64, file io.Reader) (int64, error) {
 	var totalLen int64
@@ -279,36 +1843,50 @@ func getEndByte(maxBytes, startOffset int64, file io.Reader) (int64, error) {
 			return 0, err
 		}
 		totalLen = info.Size()
+		// Pipes, FIFOs and devices report a size of 0 (or nothing useful) while data flows
+		if !info.Mode().IsRegular() {
+			totalLen = unknownLength
+		}
+	case *multiFile:
+		size, err := r.size()
+		if err != nil {
+			return 0, err
+		}
+		totalLen = size
 	case *strings.Reader:
-		totalLen = int64(r.Len())
+		// Size, not Len, since offsets count from the start whatever was read already
+		totalLen = r.Size()
 	case *bytes.Buffer:
 		totalLen = int64(r.Len())
 	case *bytes.Reader:
-		totalLen = int64(r.Len())
+		totalLen = r.Size()
 	default:
 		// fallback: assume "infinite" (read until EOF)
-		totalLen = 1<<63 - 1
+		totalLen = unknownLength
 	}
 
 	if maxBytes >= 0 {
-		return startOffset + maxBytes, nil
+		// Like xxd, -l past the end of the input stops at the end
+		return min(startOffset+maxBytes, totalLen), nil
 	}
 	return totalLen, nil
 }
 
 // Ensures byte grouping is valid (positive, <= cols, etc.)
 // In little endian number of octets must be a power of 2
-// If little-endian output is requested and grouping=2, set grouping to 4 (xxd -e default)
+// The -e default of 4 is set by the caller, only when no grouping was given
+// A grouping of 0 makes the whole line one group, an unbroken run of hex as in xxd -g 0.
+// With -e that reverses the whole line, so the line has to be a power of 2 as well.
 func validateByteGrouping(groupSize, bytesPerLine int, littleEndian bool) (int, error) {
 	switch {
+	case groupSize == 0 && littleEndian && !isPowerOfTwo(bytesPerLine):
+		return 0, fmt.Errorf("-g 0 with -e needs -c to be a power of 2, got %d", bytesPerLine)
+	case groupSize == 0:
+		return bytesPerLine, nil
 	case littleEndian && !isPowerOfTwo(groupSize):
 		return 0, fmt.Errorf("number of octets per group must be a power of 2 with -e")
-	case littleEndian && groupSize == defaultGroupSize:
-		return defaultGroupSizeLittleEndian, nil
 	case groupSize < 0:
 		return defaultGroupSize, nil
-	case groupSize == 0:
-		return bytesPerLine, nil
 	case groupSize > bytesPerLine:
 		return bytesPerLine, nil
 	default:
@@ -327,52 +1905,89 @@ func isPowerOfTwo(n int) bool {
 	return n > 0 && (n&(n-1)) == 0
 }
 
-// hexFieldWidth calculates the total width of the hex field before the ASCII panel.
-// This ensures the ASCII column always aligns,
-// especially in little-endian mode with odd group/column sizes.
-//
-// The width includes:
-//   - 2 hex digits per byte
-//   - 1 space after each group
-//   - 2 extra spaces for the gap before ASCII (as xxd does)
-//   - offsetCharWidth, which accounts for the "00000000: " offset prefix
-//
-// Example:
-//
-//	For cols=11, group=2:
-//	  numGroups = (11 + 2 - 1) / 2 = 6
-//	  width = 6 * (2*2 + 1) = 6 * 5 = 30
-//	  width += 2 (extra spaces) = 32
-//	  return width + offsetCharWidth
-//
-// Helper for problematic little endian spacing before ascii
-func hexFieldWidth(cols, group int) int {
+// littleEndianHexWidth returns the width printLittleEndianHex produces for a full line,
+// group separators included but without the gap before ascii.
+// Every group takes its full width, a partial last group is left padded, so for
+// cols=11, group=4, spaces=1 that's 3 * (4*2 + 1) = 27.
+func littleEndianHexWidth(cols, group, spaces int) int {
+	numGroups := (cols + group - 1) / group
+	return numGroups * (group*2 + spaces)
+}
+
+// bigEndianHexWidth returns the width printHex produces for a full line,
+// group separators included but without the gap before ascii.
+// Unlike -e, a partial last group isn't padded, it only gets its separator.
+func bigEndianHexWidth(cols, group, spaces int) int {
 	numGroups := (cols + group - 1) / group
-	width := numGroups * (group*2 + 1)
-	// gap before ascii
-	width += 2
+	return cols*2 + numGroups*spaces
+}
 
-	return width + offsetCharWidth
+// lineWidth returns the length of a full output line (offset, hex field, gap and ASCII panel)
+// for the given column count, byte grouping and group spacing.
+func lineWidth(cols, group, spaces int, littleEndian bool) int {
+	if littleEndian {
+		return offsetCharWidth + littleEndianHexWidth(cols, group, spaces) + 1 + cols
+	}
+	width := offsetCharWidth + bigEndianHexWidth(cols, min(group, cols), spaces)
+	// gap before ascii, then one char per byte
+	return width + 1 + cols
 }
 
-// revertToBinary reads a hex dump and writes the decoded binary to output.
-func revertToBinary(file io.Reader, output io.Writer) error {
-	writer := bufio.NewWriter(output)
-	scanner := bufio.NewScanner(file)
+// fullLineWidth returns the length of a full output line with cols bytes, like lineWidth
+// but following the layout the flags pick: -b digits, --units 16, --no-ascii,
+// --ascii-width and the columns --both-endian, --parity, --end-offset-col, --percent
+// and --timestamps add. Offsets are assumed to take the usual 8 digits.
+func (cmd *command) fullLineWidth(cols int) int {
+	if cmd.units == 16 {
+		// 4 digits and a space per unit, then one character per unit in the text panel
+		units := (cols + 1) / 2
+		return offsetCharWidth + units*len("0000 ") + 1 + units
+	}
 
-	for scanner.Scan() {
-		// Skip offset (first 10 chars), split at double space between hex and ascii
-		line := strings.Split(scanner.Text()[offsetCharWidth:], "  ")
-		cleanLine := strings.ReplaceAll(line[0], " ", "") // Remove spaces from hex
-		hexLine, err := hex.DecodeString(cleanLine)       // Decode hex to bytes
-		if err != nil {
-			return fmt.Errorf("error decoding string as hex: %v", err)
+	spaces := cmd.groupSpacing()
+	group := min(cmd.groupSize, cols)
+	var hexWidth int
+	switch {
+	case cmd.binary:
+		// 8 digits per byte instead of 2
+		hexWidth = bigEndianHexWidth(cols, group, spaces) + cols*6
+	case cmd.bothEndian:
+		// Both hex fields take the width of the wider one
+		hexWidth = 2 * max(bigEndianHexWidth(cols, group, spaces), littleEndianHexWidth(cols, group, spaces))
+	case cmd.littleEndian:
+		hexWidth = littleEndianHexWidth(cols, group, spaces)
+	default:
+		hexWidth = bigEndianHexWidth(cols, group, spaces)
+	}
+
+	panel := cols
+	if cmd.asciiWidth > 0 {
+		panel = min(panel, cmd.asciiWidth)
+	}
+	if cmd.noASCII {
+		panel = 0
+	}
+	// The gap before the panel is written even without one, it's only trimmed off bare lines
+	width := offsetCharWidth + cmd.extraColumnsWidth() + hexWidth + 1 + panel
+	if cmd.endOffsetCol {
+		width += len("  ") + offsetCharWidth - len(": ")
+	}
+	if cmd.parity {
+		width += len("  00")
+	}
+	return width
+}
+
+// fitColumns returns the largest column count, not above cmd.bytesPerLine, whose lines fit within width.
+// --units 16 only gets whole code units.
+func (cmd *command) fitColumns(width int) (int, error) {
+	for c := cmd.bytesPerLine; c > 0; c-- {
+		if cmd.units == 16 && c%2 != 0 {
+			continue
 		}
-		_, err = writer.Write(hexLine)
-		if err != nil {
-			return fmt.Errorf("error writing to stdout: %v", err)
+		if cmd.fullLineWidth(c) <= width {
+			return c, nil
 		}
 	}
-	writer.Flush()
-	return nil
+	return 0, fmt.Errorf("width %d is too narrow to fit a single byte per line", width)
 }
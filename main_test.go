@@ -1,9 +1,17 @@
-package main
+package ccxxd
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
+	"unicode/utf8"
 )
 
 func TestXxdUnitRun(t *testing.T) {
@@ -37,8 +45,8 @@ func TestXxdUnitRun(t *testing.T) {
 			maxBytes:     -1,
 			startOffset:  0,
 			input:        "Hello123?$€Æ😊",
-			want: `00000000: 6c6c6548 3332316f 82e2243f f086c3ac   Hello123?$......
-00000010:   8a989f                              ...
+			want: `00000000: 6c6c6548 3332316f 82e2243f f086c3ac  Hello123?$......
+00000010:   8a989f                             ...
 `,
 		},
 		{
@@ -83,7 +91,7 @@ func TestXxdUnitRun(t *testing.T) {
 			maxBytes:     -1,
 			startOffset:  0,
 			input:        "ABCDE",
-			want: `00000000: 44434241       45   ABCDE
+			want: `00000000: 44434241       45  ABCDE
 `,
 		},
 		{
@@ -139,8 +147,8 @@ func TestXxdUnitRun(t *testing.T) {
 			maxBytes:     -1,
 			startOffset:  0,
 			input:        "ABCDEhellogoodbye",
-			want: `00000000: 44434241 6c656845   676f6c   ABCDEhellog
-0000000b: 62646f6f     6579            oodbye
+			want: `00000000: 44434241 6c656845   676f6c  ABCDEhellog
+0000000b: 62646f6f     6579           oodbye
 `,
 		},
 	}
@@ -166,19 +174,826 @@ func TestXxdUnitRun(t *testing.T) {
 	}
 }
 
-func TestRevertToBinary(t *testing.T) {
-	original := []byte("Hello, world!\n")
-	hexDump := "00000000: 4865 6c6c 6f2c 2077 6f72 6c64 210a       Hello, world!.\n"
+func TestPercent(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("abcdefghijklmnopqrstuvwxyz012345"),
+		bytesPerLine: 8,
+		groupSize:    4,
+		maxBytes:     -1,
+		percent:      true,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `00000000:   0% 61626364 65666768  abcdefgh
+00000008:  25% 696a6b6c 6d6e6f70  ijklmnop
+00000010:  50% 71727374 75767778  qrstuvwx
+00000018:  75% 797a3031 32333435  yz012345
+`
+	assertEqual(t, out.String(), want)
+
+	// Size of a plain reader is unknown, so no percentage column
+	out.Reset()
+	cmd.input = struct{ io.Reader }{strings.NewReader("abc")}
+	cmd.percent = true
+	assertNoError(t, cmd.run())
+	assertEqual(t, out.String(), "00000000: 616263             abc\n")
+}
+
+func TestTimestamps(t *testing.T) {
+	now := time.Date(2025, 6, 2, 12, 30, 0, 0, time.UTC)
+	clock := func() time.Time {
+		now = now.Add(15 * time.Millisecond)
+		return now
+	}
+
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("abcdefghijkl"),
+		bytesPerLine: 4,
+		groupSize:    4,
+		littleEndian: true,
+		maxBytes:     -1,
+		timestamps:   true,
+		clock:        clock,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `12:30:00.015 00000000: 64636261  abcd
+12:30:00.030 00000004: 68676665  efgh
+12:30:00.045 00000008: 6c6b6a69  ijkl
+`
+	assertEqual(t, out.String(), want)
+}
+
+func TestTemplate(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("Hello, world"),
+		bytesPerLine: 8,
+		groupSize:    4,
+		maxBytes:     -1,
+		template:     "{offset} [{len}] {hex} |{ascii}|",
+	}
+	assertNoError(t, cmd.run())
+
+	want := `00000000 [8] 48656c6c 6f2c2077 |Hello, w|
+00000008 [4] 6f726c64 |orld|
+`
+	assertEqual(t, out.String(), want)
+
+	// {offset} is written like the offset column, -o and -d included
+	out.Reset()
+	cmd.input = strings.NewReader("Hello, world")
+	cmd.displayOffset = 0x100
+	cmd.decimal = true
+	assertNoError(t, cmd.run())
+	want = `00000256 [8] 48656c6c 6f2c2077 |Hello, w|
+00000264 [4] 6f726c64 |orld|
+`
+	assertEqual(t, out.String(), want)
+}
+
+func TestRTL(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("ABCDEFGHIJK"),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		rtl:          true,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `00000000: 4748 4546 4344 4142  ABCDEFGH
+00000008: 4b 494a              IJK
+`
+	assertEqual(t, out.String(), want)
+}
+
+func TestASCIIWidth(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("The quick brown fox jumps over the lazy dog"),
+		bytesPerLine: 32,
+		groupSize:    2,
+		maxBytes:     -1,
+		asciiWidth:   16,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `00000000: 5468 6520 7175 6963 6b20 6272 6f77 6e20 666f 7820 6a75 6d70 7320 6f76 6572 2074  The quick brown 
+00000020: 6865 206c 617a 7920 646f 67                                                      he lazy dog
+`
+	assertEqual(t, out.String(), want)
+
+	// -r reads the hex field by its groups, the panel is shorter than the line
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(out.String()), &reverted, revertOptions{}))
+	assertEqual(t, reverted.String(), "The quick brown fox jumps over the lazy dog")
+}
+
+func TestMinLines(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("hi"),
+		bytesPerLine: 4,
+		groupSize:    2,
+		maxBytes:     -1,
+		minLines:     3,
+	}
+	assertNoError(t, cmd.run())
+
+	want := "00000000: 6869       hi\n" +
+		"00000004: .... ....  ....\n" +
+		"00000008: .... ....  ....\n"
+	assertEqual(t, out.String(), want)
+
+	// Placeholder lines hold no bytes, so with --pad-char the dump still reverts to the input
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(&out, &reverted, revertOptions{padChar: '.'}))
+	assertEqual(t, reverted.String(), "hi")
+}
+
+func TestEndOffsetColumn(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("abcdefghijk"),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		endOffsetCol: true,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `00000000: 6162 6364 6566 6768  abcdefgh  00000007
+00000008: 696a 6b              ijk       0000000a
+`
+	assertEqual(t, out.String(), want)
+}
+
+func TestStable(t *testing.T) {
+	dump := func(input string) string {
+		var out bytes.Buffer
+		cmd := command{
+			output:       &out,
+			input:        strings.NewReader(input),
+			bytesPerLine: 16,
+			groupSize:    2,
+			maxBytes:     -1,
+			stable:       true,
+		}
+		assertNoError(t, cmd.run())
+		return out.String()
+	}
+
+	before := dump("Hello, world!\n")
+	after := dump("Hello, World!\n")
+
+	assertEqual(t, strings.Join(strings.Split(before, "\n")[:3], "\n"), `00000000: 48  H
+00000001: 65  e
+00000002: 6c  l`)
+
+	beforeLines := strings.Split(before, "\n")
+	afterLines := strings.Split(after, "\n")
+	if len(beforeLines) != len(afterLines) {
+		t.Fatalf("line count changed from %d to %d", len(beforeLines), len(afterLines))
+	}
+	var changed []string
+	for i := range beforeLines {
+		if beforeLines[i] != afterLines[i] {
+			changed = append(changed, afterLines[i])
+		}
+	}
+	if len(changed) != 1 || changed[0] != "00000007: 57  W" {
+		t.Errorf("expected exactly one changed line, got %q", changed)
+	}
+}
+
+func TestFind(t *testing.T) {
+	// 0x0a at offsets 3, 4, 18 and 21
+	input := "abc\n\nefghijklmnopq\nrs\ntuv"
+
+	tests := []struct {
+		name        string
+		startOffset int64
+		maxBytes    int64
+		want        string
+	}{
+		{name: "whole input", maxBytes: -1, want: "00000003\n00000004\n00000012\n00000015\n"},
+		{name: "with -s", startOffset: 5, maxBytes: -1, want: "00000012\n00000015\n"},
+		{name: "with -l", maxBytes: 19, want: "00000003\n00000004\n00000012\n"},
+		{name: "no match in range", startOffset: 5, maxBytes: 10, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader(input),
+				bytesPerLine: 8,
+				groupSize:    2,
+				startOffset:  tt.startOffset,
+				maxBytes:     tt.maxBytes,
+				useFind:      true,
+				findByte:     0x0a,
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
+
+func TestGroupLines(t *testing.T) {
+	input := "abcdefghijklmnopqrstuvwxyz"
+
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader(input),
+		bytesPerLine: 2,
+		groupSize:    2,
+		maxBytes:     -1,
+		groupLines:   4,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `00000000: 6162  ab
+00000002: 6364  cd
+00000004: 6566  ef
+00000006: 6768  gh
+
+00000008: 696a  ij
+0000000a: 6b6c  kl
+0000000c: 6d6e  mn
+0000000e: 6f70  op
+
+00000010: 7172  qr
+00000012: 7374  st
+00000014: 7576  uv
+00000016: 7778  wx
 
-	input := strings.NewReader(hexDump)
-	var output bytes.Buffer
+00000018: 797a  yz
+`
+	assertEqual(t, out.String(), want)
 
-	err := revertToBinary(input, &output)
+	// Blank lines are skipped when reverting
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(&out, &reverted, revertOptions{}))
+	assertEqual(t, reverted.String(), input)
+}
+
+func TestShowHexASCII(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("\x7fELF\x02\x01\x01\x00abc"),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		showHexASCII: true,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `00000000: <7f>ELF<02><01><01><00>
+00000008: abc
+`
+	assertEqual(t, out.String(), want)
+
+	var errOut bytes.Buffer
+	code := runMain([]string{"--show-hex-ascii", "--csv"}, strings.NewReader(""), &out, &errOut)
+	if code != exitUsage {
+		t.Fatalf("exit code %d, want %d, stderr: %q", code, exitUsage, errOut.String())
+	}
+}
+
+func TestTeeInput(t *testing.T) {
+	input := "tee\x00\x01\x02 raw bytes\n"
+	teeName := filepath.Join(t.TempDir(), "tee.bin")
+
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader(input),
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     -1,
+	}
+	assertNoError(t, cmd.teeInput(teeName))
+	assertNoError(t, cmd.run())
+	assertNoError(t, cmd.teeFile.Close())
+
+	want := `00000000: 7465 6500 0102 2072 6177 2062 7974 6573  tee... raw bytes
+00000010: 0a                                       .
+`
+	assertEqual(t, out.String(), want)
+
+	teed, err := os.ReadFile(teeName)
 	assertNoError(t, err)
+	assertEqual(t, string(teed), input)
+}
+
+func TestTeeInputSeek(t *testing.T) {
+	input := "0123456789abcdef"
+	dir := t.TempDir()
+	inputName := filepath.Join(dir, "input.bin")
+	assertNoError(t, os.WriteFile(inputName, []byte(input), 0o644))
+	teeName := filepath.Join(dir, "tee.bin")
+	rangesName := filepath.Join(dir, "ranges.txt")
+	assertNoError(t, os.WriteFile(rangesName, []byte("0:2\n8:2\n"), 0o644))
+
+	// The input stays seekable, so -s and -l pick the bytes that are dumped and teed
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "seek and length", args: []string{"-s", "4", "-l", "6", inputName}, want: input[4:10]},
+		{name: "seek from the end", args: []string{"-s", "-5", inputName}, want: input[11:]},
+		{name: "ranges", args: []string{"--ranges", rangesName, inputName}, want: "0189"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			code := runMain(append([]string{"--tee", teeName}, tt.args...), nil, &out, &errOut)
+			if code != exitOK {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+			}
+			teed, err := os.ReadFile(teeName)
+			assertNoError(t, err)
+			assertEqual(t, string(teed), tt.want)
+		})
+	}
+}
+
+func TestTrimTrailing(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("trailing spaces  "),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		minLines:     4,
+		trimTrailing: true,
+	}
+	assertNoError(t, cmd.run())
+
+	// Space bytes at the end of the panel are kept, and --min-lines rows end in placeholders
+	want := "00000000: 7472 6169 6c69 6e67  trailing\n" +
+		"00000008: 2073 7061 6365 7320   spaces \n" +
+		"00000010: 20                    \n" +
+		"00000018: .... .... .... ....  ........\n"
+	assertEqual(t, out.String(), want)
+}
+
+func TestTrim(t *testing.T) {
+	input := "Hello, World! abcdefg"
+
+	tests := []struct {
+		name string
+		cmd  command
+		want string
+	}{
+		{
+			name: "partial last line",
+			cmd:  command{bytesPerLine: 16, groupSize: 2},
+			want: "00000000: 4865 6c6c 6f2c 2057 6f72 6c64 2120 6162  Hello, World! ab\n" +
+				"00000010: 6364 6566 67  cdefg\n",
+		},
+		{
+			name: "partial last line ending on a group boundary",
+			cmd:  command{bytesPerLine: 8, groupSize: 5},
+			want: "00000000: 48656c6c6f 2c2057  Hello, W\n" +
+				"00000008: 6f726c6421 206162  orld! ab\n" +
+				"00000010: 6364656667  cdefg\n",
+		},
+		{
+			name: "little-endian keeps the partial group padding",
+			cmd:  command{bytesPerLine: 16, groupSize: 4, littleEndian: true},
+			want: "00000000: 6c6c6548 57202c6f 646c726f 62612021  Hello, World! ab\n" +
+				"00000010: 66656463       67  cdefg\n",
+		},
+		{
+			name: "binary",
+			cmd:  command{bytesPerLine: 6, groupSize: 1, binary: true},
+			want: "00000000: 01001000 01100101 01101100 01101100 01101111 00101100  Hello,\n" +
+				"00000006: 00100000 01010111 01101111 01110010 01101100 01100100   World\n" +
+				"0000000c: 00100001 00100000 01100001 01100010 01100011 01100100  ! abcd\n" +
+				"00000012: 01100101 01100110 01100111  efg\n",
+		},
+		{
+			name: "end offset follows the short panel",
+			cmd:  command{bytesPerLine: 16, groupSize: 2, endOffsetCol: true},
+			want: "00000000: 4865 6c6c 6f2c 2057 6f72 6c64 2120 6162  Hello, World! ab  0000000f\n" +
+				"00000010: 6364 6566 67  cdefg  00000014\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := tt.cmd
+			cmd.output = &out
+			cmd.input = strings.NewReader(input)
+			cmd.maxBytes = -1
+			cmd.trimPadding = true
+			cmd.trimTrailing = true
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tt.want)
+
+			if cmd.binary {
+				return
+			}
+			var reverted bytes.Buffer
+			opts := revertOptions{littleEndian: cmd.littleEndian, groupSize: cmd.groupSize}
+			assertNoError(t, revertToBinary(strings.NewReader(out.String()), &reverted, opts))
+			assertEqual(t, reverted.String(), input)
+		})
+	}
+}
+
+func TestTrimFlag(t *testing.T) {
+	// A space as the last byte is part of the panel, not padding
+	var out, errOut bytes.Buffer
+	code := runMain([]string{"--trim"}, strings.NewReader("ab "), &out, &errOut)
+	if code != exitOK {
+		t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+	}
+	assertEqual(t, out.String(), "00000000: 6162 20  ab \n")
+
+	// --min-lines rows are laid out as full lines, --trim leaves them as they are
+	out.Reset()
+	code = runMain([]string{"--trim", "-c", "4", "--min-lines", "2"}, strings.NewReader("ab  "), &out, &errOut)
+	if code != exitOK {
+		t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+	}
+	assertEqual(t, out.String(), "00000000: 6162 2020  ab  \n00000004: .... ....  ....\n")
+}
+
+func TestGroupSizesMatchXxd(t *testing.T) {
+	input := "The quick brown fox jumps over the lazy dog"
+
+	// Captured from: xxd -c 16 -g <groupSize>
+	tests := []struct {
+		groupSize int
+		want      string
+	}{
+		{
+			groupSize: 3,
+			want: `00000000: 546865 207175 69636b 206272 6f776e 20  The quick brown 
+00000010: 666f78 206a75 6d7073 206f76 657220 74  fox jumps over t
+00000020: 686520 6c617a 792064 6f67              he lazy dog
+`,
+		},
+		{
+			groupSize: 5,
+			want: `00000000: 5468652071 7569636b20 62726f776e 20  The quick brown 
+00000010: 666f78206a 756d707320 6f76657220 74  fox jumps over t
+00000020: 6865206c61 7a7920646f 67             he lazy dog
+`,
+		},
+		{
+			groupSize: 6,
+			want: `00000000: 546865207175 69636b206272 6f776e20  The quick brown 
+00000010: 666f78206a75 6d7073206f76 65722074  fox jumps over t
+00000020: 6865206c617a 7920646f67             he lazy dog
+`,
+		},
+		{
+			groupSize: 7,
+			want: `00000000: 54686520717569 636b2062726f77 6e20  The quick brown 
+00000010: 666f78206a756d 7073206f766572 2074  fox jumps over t
+00000020: 6865206c617a79 20646f67             he lazy dog
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("-g %d", tt.groupSize), func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader(input),
+				bytesPerLine: 16,
+				groupSize:    tt.groupSize,
+				maxBytes:     -1,
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
+
+func TestGroupZero(t *testing.T) {
+	input := "The quick brown fox jumps over the lazy dog"
+
+	// Captured from: xxd -g 0 -l 20 (and -e or -b)
+	tests := []struct {
+		name         string
+		cols         int
+		littleEndian bool
+		binary       bool
+		maxBytes     int64
+		want         string
+	}{
+		{
+			name:     "unbroken hex",
+			cols:     8,
+			maxBytes: 20,
+			want: `00000000: 5468652071756963  The quic
+00000008: 6b2062726f776e20  k brown 
+00000010: 666f7820          fox 
+`,
+		},
+		{
+			name:         "little-endian reverses the whole line",
+			cols:         8,
+			littleEndian: true,
+			maxBytes:     20,
+			want: `00000000: 6369757120656854  The quic
+00000008: 206e776f7262206b  k brown 
+00000010:         20786f66  fox 
+`,
+		},
+		{
+			name:     "binary",
+			cols:     3,
+			binary:   true,
+			maxBytes: 5,
+			want: `00000000: 010101000110100001100101  The
+00000003: 0010000001110001           q
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groupSize, err := validateByteGrouping(0, tt.cols, tt.littleEndian)
+			assertNoError(t, err)
+
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader(input),
+				bytesPerLine: tt.cols,
+				groupSize:    groupSize,
+				littleEndian: tt.littleEndian,
+				binary:       tt.binary,
+				maxBytes:     tt.maxBytes,
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+
+	// -e can only reverse whole lines whose length is a power of 2
+	if _, err := validateByteGrouping(0, 12, true); err == nil {
+		t.Errorf("expected -e -g 0 -c 12 to be rejected")
+	}
+}
+
+func TestNoASCII(t *testing.T) {
+	input := "Hello, no ascii panel here!"
+	tests := []struct {
+		name      string
+		cols      int
+		groupSize int
+		decimal   bool
+		want      string
+	}{
+		{
+			name:      "default layout",
+			cols:      16,
+			groupSize: 2,
+			want: `00000000: 4865 6c6c 6f2c 206e 6f20 6173 6369 6920
+00000010: 7061 6e65 6c20 6865 7265 21
+`,
+		},
+		{
+			name:      "odd columns and groups",
+			cols:      10,
+			groupSize: 4,
+			decimal:   true,
+			want: `00000000: 48656c6c 6f2c206e 6f20
+00000010: 61736369 69207061 6e65
+00000020: 6c206865 726521
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader(input),
+				bytesPerLine: tt.cols,
+				groupSize:    tt.groupSize,
+				decimal:      tt.decimal,
+				maxBytes:     -1,
+				noASCII:      true,
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
 
-	got := output.Bytes()
-	if !bytes.Equal(got, original) {
-		t.Errorf("output does not match original\nGOT:  %q\nWANT: %q", got, original)
+func TestGroupSpaces(t *testing.T) {
+	tests := []struct {
+		name         string
+		groupSize    int
+		littleEndian bool
+		want         string
+	}{
+		{
+			name:      "Big endian",
+			groupSize: 2,
+			want: `00000000: 6162  6364  6566  6768   abcdefgh
+00000008: 696a  6b                 ijk
+`,
+		},
+		{
+			name:      "Big endian, partial last group",
+			groupSize: 3,
+			want: `00000000: 616263  646566  6768   abcdefgh
+00000008: 696a6b                 ijk
+`,
+		},
+		{
+			name:         "Little endian",
+			groupSize:    4,
+			littleEndian: true,
+			want: `00000000: 64636261  68676665   abcdefgh
+00000008:   6b6a69             ijk
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader("abcdefghijk"),
+				bytesPerLine: 8,
+				groupSize:    tc.groupSize,
+				littleEndian: tc.littleEndian,
+				maxBytes:     -1,
+				groupSpaces:  2,
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tc.want)
+
+			// ASCII starts in the same column on the full and the partial line
+			lines := strings.Split(out.String(), "\n")
+			if strings.Index(lines[0], "abc") != strings.Index(lines[1], "ijk") {
+				t.Errorf("ASCII panel not aligned:\n%s", out.String())
+			}
+			if width := lineWidth(8, tc.groupSize, 2, tc.littleEndian); len(lines[0]) != width {
+				t.Errorf("lineWidth is %d, full line is %d chars", width, len(lines[0]))
+			}
+		})
+	}
+}
+
+func TestLittleEndianPadding(t *testing.T) {
+	input := "The quick brown fox jumps over a lazy cat"
+
+	for _, cols := range []int{11, 13, 15} {
+		for _, groupSize := range []int{4, 8} {
+			t.Run(fmt.Sprintf("-c %d -g %d", cols, groupSize), func(t *testing.T) {
+				var out bytes.Buffer
+				cmd := command{
+					output:       &out,
+					input:        strings.NewReader(input),
+					bytesPerLine: cols,
+					groupSize:    groupSize,
+					littleEndian: true,
+					maxBytes:     -1,
+				}
+				assertNoError(t, cmd.run())
+
+				lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+				asciiCol := lineWidth(cols, groupSize, 1, true) - cols
+				for i, line := range lines {
+					chunk := input[i*cols : min((i+1)*cols, len(input))]
+					// The ASCII panel starts in the same column on every line, two spaces after the hex
+					if !strings.HasSuffix(line, "  "+chunk) || len(line)-len(chunk) != asciiCol {
+						t.Errorf("line %d: ASCII %q not at column %d:\n%s", i, chunk, asciiCol, out.String())
+					}
+					if strings.HasSuffix(line[:asciiCol], "   ") && len(chunk) == cols {
+						t.Errorf("line %d: more than two spaces before the ASCII panel: %q", i, line)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestBothEndian(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("ABCDEFGHIJ"),
+		bytesPerLine: 8,
+		groupSize:    4,
+		maxBytes:     -1,
+		bothEndian:   true,
+	}
+	assertNoError(t, cmd.run())
+
+	want := "00000000: 41424344 45464748 44434241 48474645  ABCDEFGH\n" +
+		"00000008: 494a                  4a49           IJ\n"
+	assertEqual(t, out.String(), want)
+}
+
+func TestBinary(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("Hello, world! This is bits.\n"),
+		bytesPerLine: defaultColsBinary,
+		groupSize:    defaultGroupSizeBinary,
+		maxBytes:     -1,
+		binary:       true,
+	}
+	assertNoError(t, cmd.run())
+
+	// Captured from: xxd -b
+	want := `00000000: 01001000 01100101 01101100 01101100 01101111 00101100  Hello,
+00000006: 00100000 01110111 01101111 01110010 01101100 01100100   world
+0000000c: 00100001 00100000 01010100 01101000 01101001 01110011  ! This
+00000012: 00100000 01101001 01110011 00100000 01100010 01101001   is bi
+00000018: 01110100 01110011 00101110 00001010                    ts..
+`
+	assertEqual(t, out.String(), want)
+}
+
+func TestFitColumns(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		layout command // Flags that shape the line, bytesPerLine is fitted
+		want   int
+	}{
+		{name: "Default layout fits 80 chars", width: 80, layout: command{groupSize: 2}, want: 16},
+		{name: "Narrow width 40", width: 40, layout: command{groupSize: 2}, want: 8},
+		{name: "Narrow width 40, little endian", width: 40, layout: command{groupSize: 4, littleEndian: true}, want: 8},
+		{name: "Exact width of 4 columns", width: 25, layout: command{groupSize: 2}, want: 4},
+		{name: "Parity column", width: 67, layout: command{groupSize: 2, parity: true}, want: 14},
+		{name: "End offset column", width: 67, layout: command{groupSize: 2, endOffsetCol: true}, want: 13},
+		{name: "Timestamps", width: 67, layout: command{groupSize: 2, timestamps: true}, want: 12},
+		{name: "Both endian", width: 67, layout: command{groupSize: 4, bothEndian: true}, want: 8},
+		{name: "Binary digits", width: 40, layout: command{groupSize: 1, binary: true}, want: 2},
+		{name: "Units 16", width: 40, layout: command{groupSize: 2, units: 16}, want: 8},
+		{name: "No ascii", width: 40, layout: command{groupSize: 2, noASCII: true}, want: 11},
+		{name: "Ascii width", width: 40, layout: command{groupSize: 2, asciiWidth: 4}, want: 10},
+		{name: "Ascii width with end offset column", width: 60, layout: command{groupSize: 2, asciiWidth: 4, endOffsetCol: true}, want: 14},
+	}
+
+	input := strings.Repeat("abcdefghij", 5)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := tc.layout
+			cmd.bytesPerLine = defaultCols
+			cols, err := cmd.fitColumns(tc.width)
+			assertNoError(t, err)
+			if cols != tc.want {
+				t.Errorf("got %d columns, want %d", cols, tc.want)
+			}
+
+			var out bytes.Buffer
+			cmd.output = &out
+			cmd.input = strings.NewReader(input)
+			cmd.bytesPerLine = cols
+			cmd.groupSize = min(cmd.groupSize, cols)
+			cmd.maxBytes = -1
+			assertNoError(t, cmd.run())
+
+			for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+				// Counted in characters, --units 16 decodes its text panel from UTF-16
+				if utf8.RuneCountInString(line) > tc.width {
+					t.Errorf("line exceeds width %d: %q", tc.width, line)
+				}
+			}
+		})
+	}
+
+	cmd := command{bytesPerLine: defaultCols, groupSize: 2}
+	_, err := cmd.fitColumns(10)
+	if err == nil {
+		t.Errorf("expected error for width too narrow to fit a byte")
 	}
 }
 
@@ -195,3 +1010,1033 @@ func assertEqual(t testing.TB, got, want string) {
 		t.Errorf("GOT:\n%s\n\nWANT:\n%s\n", got, want)
 	}
 }
+
+func TestUppercase(t *testing.T) {
+	original := "\xca\xfe\xba\xbe uppercase\x0a"
+
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        strings.NewReader(original),
+		bytesPerLine: 10,
+		groupSize:    2,
+		maxBytes:     -1,
+		uppercase:    true,
+	}
+	assertNoError(t, cmd.run())
+
+	// Offsets are upper case too
+	want := `00000000: CAFE BABE 2075 7070 6572  .... upper
+0000000A: 6361 7365 0A              case.
+`
+	assertEqual(t, dump.String(), want)
+
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(dump.String()), &reverted, revertOptions{}))
+	assertEqual(t, reverted.String(), original)
+}
+
+func TestUppercaseLittleEndian(t *testing.T) {
+	original := "Hello, World\xca\xfe"
+
+	tests := []struct {
+		name string
+		cmd  command
+		want string
+	}{
+		{
+			// Captured from: xxd -e -u
+			name: "-e -u",
+			cmd:  command{bytesPerLine: 16, groupSize: 4, littleEndian: true},
+			want: "00000000: 6C6C6548 57202C6F 646C726F     FECA  Hello, World..\n",
+		},
+		{
+			name: "-e -u -g 2",
+			cmd:  command{bytesPerLine: 8, groupSize: 2, littleEndian: true},
+			want: "00000000: 6548 6C6C 2C6F 5720  Hello, W\n" +
+				"00000008: 726F 646C FECA       orld..\n",
+		},
+		{
+			name: "--both-endian -u",
+			cmd:  command{bytesPerLine: 8, groupSize: 4, bothEndian: true},
+			want: "00000000: 48656C6C 6F2C2057 6C6C6548 57202C6F  Hello, W\n" +
+				"00000008: 6F726C64 CAFE     646C726F     FECA  orld..\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dump bytes.Buffer
+			cmd := tt.cmd
+			cmd.output = &dump
+			cmd.input = strings.NewReader(original)
+			cmd.maxBytes = -1
+			cmd.uppercase = true
+			assertNoError(t, cmd.run())
+			assertEqual(t, dump.String(), tt.want)
+
+			if !cmd.littleEndian {
+				return
+			}
+			var reverted bytes.Buffer
+			opts := revertOptions{littleEndian: true, groupSize: cmd.groupSize}
+			assertNoError(t, revertToBinary(strings.NewReader(dump.String()), &reverted, opts))
+			assertEqual(t, reverted.String(), original)
+		})
+	}
+}
+
+func TestPlaceholder(t *testing.T) {
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        strings.NewReader("tab\there\x00\xff"),
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     -1,
+		placeholder:  '?',
+	}
+	assertNoError(t, cmd.run())
+	assertEqual(t, dump.String(), "00000000: 7461 6209 6865 7265 00ff                 tab?here??\n")
+
+	for _, valid := range []string{"?", " ", "."} {
+		if _, err := parsePlaceholder(valid); err != nil {
+			t.Errorf("parsePlaceholder(%q): %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"", "??", "\t", "\x7f", "é"} {
+		if _, err := parsePlaceholder(invalid); err == nil {
+			t.Errorf("parsePlaceholder(%q): expected error", invalid)
+		}
+	}
+}
+
+func TestDecimalOffset(t *testing.T) {
+	original := "decimal offsets here, 40 bytes long!!!!"
+
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        strings.NewReader(original),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		decimal:      true,
+	}
+	assertNoError(t, cmd.run())
+
+	// Captured from: xxd -d -c 8
+	want := `00000000: 6465 6369 6d61 6c20  decimal 
+00000008: 6f66 6673 6574 7320  offsets 
+00000016: 6865 7265 2c20 3430  here, 40
+00000024: 2062 7974 6573 206c   bytes l
+00000032: 6f6e 6721 2121 21    ong!!!!
+`
+	assertEqual(t, dump.String(), want)
+
+	// Offsets past 8 digits widen the column instead of being cut
+	dump.Reset()
+	cmd.input = strings.NewReader("big")
+	cmd.displayOffset = 123456789
+	assertNoError(t, cmd.run())
+	assertEqual(t, dump.String(), "123456789: 6269 67              big\n")
+}
+
+func TestOffsetWidth(t *testing.T) {
+	// A sparse file just over 4 GiB, so its last offsets need 9 hex digits
+	name := filepath.Join(t.TempDir(), "big")
+	file, err := os.Create(name)
+	assertNoError(t, err)
+	defer file.Close()
+	if err := file.Truncate(1<<32 + 8); err != nil {
+		t.Skipf("can't create a sparse file: %v", err)
+	}
+	_, err = file.WriteAt([]byte("tail"), 1<<32+4)
+	assertNoError(t, err)
+
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        file,
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		startOffset:  1<<32 - 8,
+	}
+	assertNoError(t, cmd.run())
+
+	// Every line uses the wider column, so the hex stays aligned
+	want := `0fffffff8: 0000 0000 0000 0000  ........
+100000000: 0000 0000 7461 696c  ....tail
+`
+	assertEqual(t, dump.String(), want)
+}
+
+func TestPlain(t *testing.T) {
+	original := "plain mode dumps are just a stream of hex digits"
+
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        strings.NewReader(original),
+		bytesPerLine: defaultColsPlain,
+		groupSize:    2,
+		maxBytes:     -1,
+		plain:        true,
+	}
+	assertNoError(t, cmd.run())
+
+	// Captured from: xxd -p
+	want := `706c61696e206d6f64652064756d707320617265206a7573742061207374
+7265616d206f662068657820646967697473
+`
+	assertEqual(t, dump.String(), want)
+
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(dump.String()), &reverted, revertOptions{}))
+	assertEqual(t, reverted.String(), original)
+}
+
+func TestDisplayOffset(t *testing.T) {
+	input := "display offset moves only the shown addresses"
+
+	tests := []struct {
+		name          string
+		displayOffset int64
+		startOffset   int64
+		want          string
+	}{
+		{
+			name:          "positive",
+			displayOffset: 0x100,
+			startOffset:   16,
+			want: `00000110: 6f76 6573 206f 6e6c 7920 7468 6520 7368  oves only the sh
+00000120: 6f77 6e20 6164 6472 6573 7365 73         own addresses
+`,
+		},
+		{
+			name:          "negative wraps like xxd",
+			displayOffset: -16,
+			want: `fffffffffffffff0: 6469 7370 6c61 7920 6f66 6673 6574 206d  display offset m
+00000000: 6f76 6573 206f 6e6c 7920 7468 6520 7368  oves only the sh
+00000010: 6f77 6e20 6164 6472 6573 7365 73         own addresses
+`,
+		},
+		{
+			name:          "widens past 8 digits",
+			displayOffset: 0xfffffffff,
+			startOffset:   32,
+			want: `100000001f: 6f77 6e20 6164 6472 6573 7365 73         own addresses
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := command{
+				output:        &out,
+				input:         strings.NewReader(input),
+				bytesPerLine:  16,
+				groupSize:     2,
+				maxBytes:      -1,
+				startOffset:   tt.startOffset,
+				displayOffset: tt.displayOffset,
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
+
+func TestDisplayOffsetPrinters(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "end offset column", args: []string{"--end-offset-col"}, want: "00000100: 6865 6c6c 6f                             hello             00000104\n"},
+		{name: "stable", args: []string{"--stable", "-l", "2"}, want: "00000100: 68  h\n00000101: 65  e\n"},
+		{name: "show hex ascii", args: []string{"--show-hex-ascii"}, want: "00000100: hello\n"},
+		{name: "find", args: []string{"--find", "0x6c"}, want: "00000102\n00000103\n"},
+		{name: "units 16", args: []string{"--units", "16"}, want: "00000100: 6865 6c6c 6f                             \u6865\u6c6c.\n"},
+		{name: "template", args: []string{"--template", "{offset}|{hex}"}, want: "00000100|6865 6c6c 6f\n"},
+		{name: "csv", args: []string{"--csv"}, want: "offset,b0,b1,b2,b3,b4,b5,b6,b7,b8,b9,b10,b11,b12,b13,b14,b15,ascii\n00000100,68,65,6c,6c,6f,,,,,,,,,,,,hello\n"},
+		{name: "html", args: []string{"--html"}, want: "<table>\n<tr><th>offset</th><th>hex</th><th>ascii</th></tr>\n<tr><td>00000100</td><td>6865 6c6c 6f</td><td>hello</td></tr>\n</table>\n"},
+		{name: "od", args: []string{"--od"}, want: "000100 68 65 6c 6c 6f                                   >hello<\n000105\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			code := runMain(append([]string{"-o", "0x100"}, tt.args...), strings.NewReader("hello"), &out, &errOut)
+			if code != exitOK {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+			}
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
+
+func TestResolveSeek(t *testing.T) {
+	tests := []struct {
+		name    string
+		seek    string
+		input   io.Reader
+		want    int64
+		wantErr bool
+	}{
+		{name: "bare", seek: "10", input: strings.NewReader("0123456789abcdef"), want: 10},
+		{name: "hex", seek: "0x0c", input: strings.NewReader("0123456789abcdef"), want: 12},
+		{name: "from start", seek: "+4", input: strings.NewReader("0123456789abcdef"), want: 4},
+		{name: "from end", seek: "-4", input: strings.NewReader("0123456789abcdef"), want: 12},
+		{name: "before start", seek: "-17", input: strings.NewReader("0123456789abcdef"), wantErr: true},
+		{name: "from end of stream", seek: "-4", input: io.MultiReader(strings.NewReader("0123")), wantErr: true},
+		{name: "not a number", seek: "ten", input: strings.NewReader(""), wantErr: true},
+		{name: "with unit", seek: "1k", input: strings.NewReader(""), want: 1000},
+		{name: "double sign", seek: "+-4", input: strings.NewReader("0123456789abcdef"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSeek(tt.seek, tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got offset %d", got)
+				}
+				return
+			}
+			assertNoError(t, err)
+			if got != tt.want {
+				t.Errorf("got offset %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptyInput(t *testing.T) {
+	// Checked against xxd: every dump mode writes nothing for empty input,
+	// only -i still declares the (empty) array and its length
+	tests := []struct {
+		name string
+		cmd  command
+		want string
+	}{
+		{name: "normal", cmd: command{bytesPerLine: 16, groupSize: 2}, want: ""},
+		{name: "little endian", cmd: command{bytesPerLine: 16, groupSize: 4, littleEndian: true}, want: ""},
+		{name: "plain", cmd: command{bytesPerLine: defaultColsPlain, groupSize: 2, plain: true}, want: ""},
+		{name: "binary", cmd: command{bytesPerLine: defaultColsBinary, groupSize: 1, binary: true}, want: ""},
+		{name: "autoskip", cmd: command{bytesPerLine: 16, groupSize: 2, autoskip: true}, want: ""},
+		{
+			name: "c include",
+			cmd:  command{bytesPerLine: defaultColsCInclude, cInclude: true, inputName: "empty"},
+			want: "unsigned char empty[] = {\n};\nunsigned int empty_len = 0;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := tt.cmd
+			cmd.output = &out
+			cmd.input = strings.NewReader("")
+			cmd.maxBytes = -1
+			if cmd.cInclude {
+				assertNoError(t, cmd.printCInclude())
+			} else {
+				assertNoError(t, cmd.run())
+			}
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
+
+func TestZeroGroupSize(t *testing.T) {
+	// A library caller leaving groupSize unset must not divide by zero
+	for _, littleEndian := range []bool{false, true} {
+		var dump bytes.Buffer
+		cmd := command{
+			output:       &dump,
+			input:        strings.NewReader("no group size set"),
+			bytesPerLine: 8,
+			maxBytes:     -1,
+			littleEndian: littleEndian,
+		}
+		assertNoError(t, cmd.run())
+		if cmd.groupSize != 8 {
+			t.Errorf("-e %v: groupSize = %d, want 8", littleEndian, cmd.groupSize)
+		}
+		if !littleEndian {
+			// Captured from: xxd -g 0 -c 8
+			want := `00000000: 6e6f2067726f7570  no group
+00000008: 2073697a65207365   size se
+00000010: 74                t
+`
+			assertEqual(t, dump.String(), want)
+		}
+	}
+}
+
+func TestRunMain(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "dump stdin",
+			stdin:      "Hi\n",
+			wantCode:   exitOK,
+			wantStdout: "00000000: 4869 0a                                  Hi.\n",
+		},
+		{
+			name:       "revert stdin",
+			args:       []string{"-r"},
+			stdin:      "00000000: 4869 0a  Hi.\n",
+			wantCode:   exitOK,
+			wantStdout: "Hi\n",
+		},
+		{name: "help", args: []string{"-h"}, wantCode: exitOK, wantStderr: "Usage of ccxxd"},
+		{name: "unknown flag", args: []string{"-zzz"}, wantCode: exitUsage, wantStderr: "flag provided but not defined: -zzz"},
+		{name: "bad flag value", args: []string{"-c", "four"}, wantCode: exitUsage, wantStderr: "invalid value"},
+		{name: "conflicting flags", args: []string{"-b", "-e"}, wantCode: exitUsage, wantStderr: "-b prints bits"},
+		{name: "invalid size", args: []string{"-l", "1x"}, wantCode: exitUsage, wantStderr: "invalid -l value"},
+		{name: "negative -l", args: []string{"-l", "-5"}, wantCode: exitUsage, wantStderr: "invalid -l value: size \"-5\" is negative"},
+		{name: "negative -len", args: []string{"-len=-1"}, wantCode: exitUsage, wantStderr: "is negative"},
+		{name: "empty -l", args: []string{"-l", ""}, wantCode: exitUsage, wantStderr: "invalid -l value: invalid size \"\""},
+		{name: "zero -l", args: []string{"-l", "0"}, stdin: "Hi\n", wantCode: exitOK},
+		{
+			name:       "missing file",
+			args:       []string{filepath.Join(t.TempDir(), "missing")},
+			wantCode:   exitError,
+			wantStderr: "error opening",
+		},
+		{
+			name:       "corrupt dump",
+			args:       []string{"-r"},
+			stdin:      "00000000: 48zz  H.\n",
+			wantCode:   exitError,
+			wantStderr: "error reverting to binary: line 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runMain(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("exit code %d, want %d, stderr: %q", code, tt.wantCode, stderr.String())
+			}
+			assertEqual(t, stdout.String(), tt.wantStdout)
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr %q doesn't contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.bin")
+	dump := filepath.Join(dir, "dump.txt")
+	reverted := filepath.Join(dir, "out.bin")
+	assertNoError(t, os.WriteFile(input, []byte("to a file\n"), 0o644))
+	// Existing content is truncated, not overwritten in place
+	assertNoError(t, os.WriteFile(dump, bytes.Repeat([]byte("stale "), 100), 0o644))
+
+	run := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		code := runMain(args, strings.NewReader(""), &stdout, &stderr)
+		if stdout.Len() > 0 {
+			t.Errorf("%v wrote to stdout: %q", args, stdout.String())
+		}
+		return code, stderr.String()
+	}
+
+	code, stderr := run("--output", dump, input)
+	assertEqual(t, stderr, "")
+	if code != exitOK {
+		t.Fatalf("dump exited with %d", code)
+	}
+	got, err := os.ReadFile(dump)
+	assertNoError(t, err)
+	assertEqual(t, string(got), "00000000: 746f 2061 2066 696c 650a                 to a file.\n")
+
+	code, stderr = run("-r", "--output", reverted, dump)
+	assertEqual(t, stderr, "")
+	if code != exitOK {
+		t.Fatalf("revert exited with %d", code)
+	}
+	got, err = os.ReadFile(reverted)
+	assertNoError(t, err)
+	assertEqual(t, string(got), "to a file\n")
+
+	// Writing over the input would lose it before it's read
+	code, stderr = run("--output", input, input)
+	if code != exitError || !strings.Contains(stderr, "is also an input") {
+		t.Errorf("expected the input to be refused as output, got %d %q", code, stderr)
+	}
+	got, err = os.ReadFile(input)
+	assertNoError(t, err)
+	assertEqual(t, string(got), "to a file\n")
+
+	// A failing revert still reports its own error, the output file was already created
+	corrupt := filepath.Join(dir, "corrupt.txt")
+	assertNoError(t, os.WriteFile(corrupt, []byte("00000000: 48zz  H.\n"), 0o644))
+	code, stderr = run("-r", "--output", reverted, corrupt)
+	if code != exitError || !strings.Contains(stderr, "error reverting to binary") {
+		t.Errorf("expected the revert error, got %d %q", code, stderr)
+	}
+	_, err = os.Stat(reverted)
+	assertNoError(t, err)
+}
+
+func TestLoadCommandValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmd      command
+		setFlags []string
+		wantErr  string
+	}{
+		{name: "-b -e", cmd: command{binary: true, littleEndian: true}, wantErr: "-b prints bits in file order"},
+		{name: "-b -r", cmd: command{binary: true, revert: true}, wantErr: "-r can't read -b bit dumps"},
+		{name: "-b -p", cmd: command{binary: true, plain: true}, wantErr: "-p prints plain hex"},
+		{name: "-b -i", cmd: command{binary: true, cInclude: true}, wantErr: "-i prints hex bytes"},
+		{name: "-i -r", cmd: command{cInclude: true, revert: true}, wantErr: "-r can't read -i C include"},
+		{name: "-i -p", cmd: command{cInclude: true, plain: true}, wantErr: "-i and -p are different output formats"},
+		{name: "-e -p", cmd: command{littleEndian: true, plain: true}, wantErr: "-p prints bytes in file order"},
+		{name: "-e -i", cmd: command{littleEndian: true, cInclude: true}, wantErr: "-i prints bytes in file order"},
+		{name: "--both-endian -e", cmd: command{bothEndian: true, littleEndian: true}, wantErr: "--both-endian already shows"},
+		{name: "-r -l", cmd: command{revert: true}, setFlags: []string{"l"}, wantErr: "-l limits a dump"},
+		{name: "-r -len", cmd: command{revert: true}, setFlags: []string{"len"}, wantErr: "-l limits a dump"},
+		{name: "-r -s", cmd: command{revert: true}, setFlags: []string{"s"}, wantErr: "use -seek"},
+		{name: "--json -r", cmd: command{json: true, revert: true}, wantErr: "-r can't read --json"},
+		{name: "--json -a", cmd: command{json: true, autoskip: true}, wantErr: "into the --json array"},
+		{name: "--json --ranges", cmd: command{json: true}, setFlags: []string{"ranges"}, wantErr: "one --json array per range"},
+		{name: "--patch without -r", cmd: command{patchFile: "out.bin"}, wantErr: "--patch only works with -r"},
+		{name: "-seek without -r", cmd: command{}, setFlags: []string{"seek"}, wantErr: "-seek only works with -r"},
+		{name: "--tolerant without -r", cmd: command{tolerant: true}, wantErr: "--tolerant and --check only work with -r"},
+		{name: "--check without -r", cmd: command{check: true}, wantErr: "--tolerant and --check only work with -r"},
+		{name: "--patch --output", cmd: command{revert: true, patchFile: "out.bin"}, setFlags: []string{"output"}, wantErr: "drop --output"},
+		{name: "--patch --tolerant", cmd: command{revert: true, patchFile: "out.bin", tolerant: true}, wantErr: "--patch writes each line at its offset"},
+		{name: "--patch --dump-lines", cmd: command{revert: true, patchFile: "out.bin"}, setFlags: []string{"dump-lines"}, wantErr: "--patch writes each line at its offset"},
+		{name: "--patch --prepend-hex", cmd: command{revert: true, patchFile: "out.bin"}, setFlags: []string{"prepend-hex"}, wantErr: "--patch writes each line at its offset"},
+		{name: "--rtl -e", cmd: command{rtl: true, littleEndian: true}, wantErr: "can't be combined with -e"},
+		{name: "--template -b", cmd: command{template: "{hex}", binary: true}, wantErr: "--template prints hex"},
+		{name: "--template --percent", cmd: command{template: "{hex}", percent: true}, wantErr: "--template lays out the whole line"},
+		{name: "--template --timestamps", cmd: command{template: "{hex}", timestamps: true}, wantErr: "--template lays out the whole line"},
+		{name: "--stable -a", cmd: command{stable: true, autoskip: true}, wantErr: "--stable prints every byte"},
+		{name: "--stable --squeeze", cmd: command{stable: true, squeeze: true}, wantErr: "--stable prints every byte"},
+		{name: "--csv --ranges", cmd: command{csv: true}, setFlags: []string{"ranges"}, wantErr: "between the --csv rows"},
+		{name: "--od --json", cmd: command{od: true, json: true}, wantErr: "--od is an output format of its own"},
+		{name: "--od -p", cmd: command{od: true, plain: true}, wantErr: "--od is an output format of its own"},
+		{name: "-r --od --csv", cmd: command{revert: true, od: true, csv: true}},
+		{name: "--od-verbose without --od", cmd: command{odVerbose: true}, wantErr: "--od-verbose only works with --od"},
+		{name: "--split-output --output", cmd: command{splitLines: 10}, setFlags: []string{"output"}, wantErr: "drop --output"},
+		{name: "--skip-after without -a", cmd: command{}, setFlags: []string{"skip-after"}, wantErr: "only work with -a"},
+		{name: "--skip-marker without -a", cmd: command{}, setFlags: []string{"skip-marker"}, wantErr: "--skip-marker only works with -a"},
+		{name: "-r --skip-marker", cmd: command{revert: true}, setFlags: []string{"skip-marker"}},
+		{name: "--max-skips without -a", cmd: command{}, setFlags: []string{"max-skips"}, wantErr: "only work with -a"},
+		{name: "--poll-interval without --follow", cmd: command{}, setFlags: []string{"poll-interval"}, wantErr: "--poll-interval only works with --follow"},
+		{name: "--follow -r", cmd: command{follow: true, revert: true}, wantErr: "--follow keeps a dump going"},
+		{name: "--follow -i", cmd: command{follow: true, cInclude: true}, wantErr: "--follow keeps a dump going"},
+		{name: "--follow --ranges", cmd: command{follow: true}, setFlags: []string{"ranges"}, wantErr: "--follow keeps a dump going"},
+		{name: "--stats -a", cmd: command{stats: true, autoskip: true}, setFlags: []string{"stats", "a"}, wantErr: "--stats prints a summary"},
+		{name: "--stats -p", cmd: command{stats: true, plain: true}, setFlags: []string{"stats", "p"}, wantErr: "combined with -p"},
+		{name: "--stats --csv", cmd: command{stats: true, csv: true}, setFlags: []string{"stats", "csv"}, wantErr: "combined with --csv"},
+		{name: "--stats --split-output", cmd: command{stats: true, splitLines: 1}, setFlags: []string{"stats", "split-output"}, wantErr: "combined with --split-output"},
+		{name: "--stats --follow", cmd: command{stats: true, follow: true}, setFlags: []string{"stats", "follow"}, wantErr: "--follow keeps a dump going"},
+		{name: "--units 16 with odd -c", cmd: command{units: 16, bytesPerLine: 7}, setFlags: []string{"units", "c"}, wantErr: "--units 16 needs an even -c"},
+		{name: "--units 16 -g", cmd: command{units: 16, bytesPerLine: 16}, setFlags: []string{"units", "g"}, wantErr: "can't be combined with -g"},
+		{name: "--units 16 -c", cmd: command{units: 16, bytesPerLine: 8}, setFlags: []string{"units", "c"}},
+		{name: "-r -e", cmd: command{revert: true, littleEndian: true}},
+		{name: "-r -seek", cmd: command{revert: true}, setFlags: []string{"seek"}},
+		{name: "-b -c -g", cmd: command{binary: true}, setFlags: []string{"c", "g"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlags := map[string]bool{}
+			for _, name := range tt.setFlags {
+				setFlags[name] = true
+			}
+			err := tt.cmd.validateFlags(setFlags)
+			if tt.wantErr == "" {
+				assertNoError(t, err)
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWord(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantGroup int
+		wantErr   string
+	}{
+		{name: "8 bit", args: []string{"--word", "8"}, wantGroup: 1},
+		{name: "32 bit", args: []string{"--word", "32"}, wantGroup: 4},
+		{name: "64 bit", args: []string{"--word", "64"}, wantGroup: 8},
+		{name: "16 bit little-endian keeps 2 byte groups", args: []string{"--word", "16", "-e"}, wantGroup: 2},
+		{name: "64 bit little-endian", args: []string{"--word", "64", "-e"}, wantGroup: 8},
+		{name: "wider than the line", args: []string{"--word", "64", "-c", "4"}, wantGroup: 4},
+		{name: "overrides the -b default", args: []string{"--word", "16", "-b"}, wantGroup: 2},
+		{name: "matching -g", args: []string{"--word", "32", "-g", "4"}, wantGroup: 4},
+		{name: "conflicting -g", args: []string{"--word", "32", "-g", "2"}, wantErr: "ask for different group sizes"},
+		{name: "unknown size", args: []string{"--word", "12"}, wantErr: "--word must be 8, 16, 32 or 64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := loadCommand(tt.args, strings.NewReader(""), io.Discard, io.Discard)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			assertNoError(t, err)
+			if cmd.groupSize != tt.wantGroup {
+				t.Errorf("group size: GOT %d WANT %d", cmd.groupSize, tt.wantGroup)
+			}
+		})
+	}
+}
+
+func TestSingleLineColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    io.Reader
+		start    int64
+		maxBytes int64
+		want     int
+		wantErr  bool
+	}{
+		{name: "whole input", input: strings.NewReader("one line"), maxBytes: -1, want: 8},
+		{name: "after -s", input: strings.NewReader("one line"), start: 4, maxBytes: -1, want: 4},
+		{name: "limited by -l", input: strings.NewReader("one line"), maxBytes: 3, want: 3},
+		{name: "empty input", input: strings.NewReader(""), maxBytes: -1, want: 1},
+		{name: "unknown length", input: onlyReader{strings.NewReader("one line")}, maxBytes: -1, wantErr: true},
+		{name: "unknown length with -l", input: onlyReader{strings.NewReader("one line")}, maxBytes: 5, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := command{input: tt.input, startOffset: tt.start, maxBytes: tt.maxBytes}
+			got, err := cmd.singleLineColumns()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %d", got)
+				}
+				return
+			}
+			assertNoError(t, err)
+			if got != tt.want {
+				t.Errorf("got %d columns, want %d", got, tt.want)
+			}
+		})
+	}
+
+	// run refuses zero columns instead of looping forever
+	cmd := command{output: io.Discard, input: strings.NewReader("x"), maxBytes: -1}
+	if err := cmd.run(); err == nil {
+		t.Errorf("expected error for zero bytes per line")
+	}
+}
+
+func TestGetEndByte(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fifty.bin")
+	assertNoError(t, os.WriteFile(path, bytes.Repeat([]byte{0xab}, 50), 0o644))
+
+	tests := []struct {
+		name     string
+		start    int64
+		maxBytes int64
+		want     int64
+	}{
+		{name: "no -l", maxBytes: -1, want: 50},
+		{name: "-l larger than the file", maxBytes: 1000000, want: 50},
+		{name: "-l equal to the file", maxBytes: 50, want: 50},
+		{name: "-l smaller than the file", maxBytes: 20, want: 20},
+		{name: "-s and -l past the end", start: 40, maxBytes: 20, want: 50},
+		{name: "-s and -l within the file", start: 10, maxBytes: 20, want: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := os.Open(path)
+			assertNoError(t, err)
+			defer file.Close()
+
+			got, err := getEndByte(tt.maxBytes, tt.start, file)
+			assertNoError(t, err)
+			if got != tt.want {
+				t.Errorf("got end %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	// Unknown sizes still end where -l says
+	got, err := getEndByte(1000000, 0, onlyReader{strings.NewReader("short")})
+	assertNoError(t, err)
+	if got != 1000000 {
+		t.Errorf("got end %d for an unknown size, want 1000000", got)
+	}
+
+	// A huge -l doesn't widen the offset column past what the file needs
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("tiny"),
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     1 << 40,
+	}
+	assertNoError(t, cmd.run())
+	assertEqual(t, out.String(), "00000000: 7469 6e79                                tiny\n")
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{size: "512", want: 512},
+		{size: "0x200", want: 512},
+		{size: "1k", want: 1000},
+		{size: "1K", want: 1000},
+		{size: "2M", want: 2000000},
+		{size: "3G", want: 3000000000},
+		{size: "4kB", want: 4000},
+		{size: "1KiB", want: 1024},
+		{size: "2MiB", want: 2 << 20},
+		{size: "1gib", want: 1 << 30},
+		{size: "0x10k", want: 16000},
+		{size: "", wantErr: true},
+		{size: "k", wantErr: true},
+		{size: "1.5M", wantErr: true},
+		{size: "12 k", wantErr: true},
+		{size: "1T", wantErr: true},
+		{size: "-1k", wantErr: true},
+		{size: "9999999999G", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := parseSize(tt.size)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %d", got)
+				}
+				return
+			}
+			assertNoError(t, err)
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadErrorOffset(t *testing.T) {
+	errBadSector := errors.New("bad sector")
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        io.MultiReader(strings.NewReader(strings.Repeat("x", 40)), iotest.ErrReader(errBadSector)),
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     -1,
+	}
+	err := cmd.run()
+	if err == nil || err.Error() != "read error at offset 0x28: bad sector" {
+		t.Fatalf("expected the read error with its offset, got %v", err)
+	}
+	if !errors.Is(err, errBadSector) {
+		t.Errorf("read error isn't wrapped: %v", err)
+	}
+	// The full lines before the error are still dumped
+	assertEqual(t, out.String(), `00000000: 7878 7878 7878 7878 7878 7878 7878 7878  xxxxxxxxxxxxxxxx
+00000010: 7878 7878 7878 7878 7878 7878 7878 7878  xxxxxxxxxxxxxxxx
+`)
+}
+
+func TestFIFOInput(t *testing.T) {
+	// A pipe is an *os.File whose Stat reports size 0 while data is flowing
+	reader, writer, err := os.Pipe()
+	assertNoError(t, err)
+	defer reader.Close()
+	go func() {
+		writer.WriteString("data behind a zero size")
+		writer.Close()
+	}()
+
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        reader,
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     -1,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `00000000: 6461 7461 2062 6568 696e 6420 6120 7a65  data behind a ze
+00000010: 726f 2073 697a 65                        ro size
+`
+	assertEqual(t, dump.String(), want)
+}
+
+// onlyReader hides every method of the wrapped reader but Read, like a pipe
+type onlyReader struct {
+	io.Reader
+}
+
+func TestSkipNonSeekable(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        onlyReader{strings.NewReader("skipme: the rest is dumped")},
+		bytesPerLine: 16,
+		groupSize:    2,
+		startOffset:  8,
+		maxBytes:     -1,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `00000008: 7468 6520 7265 7374 2069 7320 6475 6d70  the rest is dump
+00000018: 6564                                     ed
+`
+	assertEqual(t, out.String(), want)
+}
+
+func TestSeekAndLength(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ten.bin")
+	assertNoError(t, os.WriteFile(path, []byte("abcdefghij"), 0o644))
+
+	// Expected output captured from: xxd -s <seek> -l <len> ten.bin
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "range within the input",
+			args: []string{"-s", "3", "-l", "5"},
+			want: "00000003: 6465 6667 68                             defgh\n",
+		},
+		{
+			name: "range ending exactly at EOF",
+			args: []string{"-s", "3", "-l", "7"},
+			want: "00000003: 6465 6667 6869 6a                        defghij\n",
+		},
+		{
+			name: "range overrunning EOF",
+			args: []string{"-s", "3", "-l", "100"},
+			want: "00000003: 6465 6667 6869 6a                        defghij\n",
+		},
+		{
+			name: "range ending one byte before EOF",
+			args: []string{"-s", "3", "-l", "6", "-c", "3"},
+			want: "00000003: 6465 66  def\n" +
+				"00000006: 6768 69  ghi\n",
+		},
+		{name: "start exactly at EOF", args: []string{"-s", "10", "-l", "5"}},
+		{name: "start beyond EOF", args: []string{"-s", "15", "-l", "5"}},
+		{name: "start beyond EOF without -l", args: []string{"-s", "15"}},
+		{name: "-l 0 at the last byte", args: []string{"-s", "9", "-l", "0"}},
+	}
+
+	for _, tt := range tests {
+		// The same range from a regular file, which seeks, and from a pipe, which skips
+		t.Run(tt.name+" file", func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runMain(append(tt.args, path), strings.NewReader(""), &stdout, &stderr)
+			if code != exitOK {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, stderr.String())
+			}
+			assertEqual(t, stdout.String(), tt.want)
+		})
+		t.Run(tt.name+" pipe", func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runMain(tt.args, onlyReader{strings.NewReader("abcdefghij")}, &stdout, &stderr)
+			if code != exitOK {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, stderr.String())
+			}
+			assertEqual(t, stdout.String(), tt.want)
+		})
+	}
+}
+
+// truncatingWriter truncates the file at path to size on its first write,
+// as if the file shrank while it was being dumped.
+type truncatingWriter struct {
+	bytes.Buffer
+	path string
+	size int64
+}
+
+func (w *truncatingWriter) Write(p []byte) (int, error) {
+	if w.path != "" {
+		if err := os.Truncate(w.path, w.size); err != nil {
+			return 0, err
+		}
+		w.path = ""
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestShrinkingInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shrinking.bin")
+	assertNoError(t, os.WriteFile(path, bytes.Repeat([]byte("0123456789abcdef"), 4096), 0o644))
+	file, err := os.Open(path)
+	assertNoError(t, err)
+	defer file.Close()
+
+	// The first buffered write happens long before the -s/-l range is read to its end
+	out := &truncatingWriter{path: path, size: 20008}
+	cmd := command{
+		output:       out,
+		input:        file,
+		bytesPerLine: 16,
+		groupSize:    2,
+		startOffset:  16,
+		maxBytes:     40000,
+	}
+	assertNoError(t, cmd.run())
+
+	// The dump stops at the new end, with a short last line
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assertEqual(t, lines[0], "00000010: 3031 3233 3435 3637 3839 6162 6364 6566  0123456789abcdef")
+	assertEqual(t, lines[len(lines)-1], "00004e20: 3031 3233 3435 3637                      01234567")
+	if len(lines) != (20008-16)/16+1 {
+		t.Errorf("got %d lines, want %d", len(lines), (20008-16)/16+1)
+	}
+}
+
+// writeCounter counts the writes it is given, to check when the output is flushed.
+type writeCounter struct {
+	writes int
+}
+
+func (w *writeCounter) Write(p []byte) (int, error) {
+	w.writes++
+	return len(p), nil
+}
+
+func TestRunFlushLines(t *testing.T) {
+	input := strings.Repeat("0123456789abcdef", 3)
+	inputName := filepath.Join(t.TempDir(), "input.bin")
+	assertNoError(t, os.WriteFile(inputName, []byte(input), 0o644))
+
+	tests := []struct {
+		name       string
+		stream     bool // Read from a stream instead of the regular file
+		timestamps bool
+		want       int
+	}{
+		{name: "regular file is flushed once", want: 1},
+		{name: "stream is flushed per line", stream: true, want: 3},
+		{name: "timestamps are flushed per line", timestamps: true, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := os.Open(inputName)
+			assertNoError(t, err)
+			defer file.Close()
+			var in io.Reader = file
+			if tt.stream {
+				in = onlyReader{file}
+			}
+
+			var out writeCounter
+			cmd := command{
+				output:       &out,
+				input:        in,
+				bytesPerLine: 16,
+				groupSize:    2,
+				maxBytes:     -1,
+				timestamps:   tt.timestamps,
+			}
+			assertNoError(t, cmd.run())
+			if out.writes != tt.want {
+				t.Errorf("output written %d times, want %d", out.writes, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	input := make([]byte, 4<<20)
+	for i := range input {
+		input[i] = byte(i * 7)
+	}
+	// A regular file, so the output is only flushed at the end
+	inputName := filepath.Join(b.TempDir(), "input.bin")
+	if err := os.WriteFile(inputName, input, 0o644); err != nil {
+		b.Fatal(err)
+	}
+	file, err := os.Open(inputName)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+
+	// A pipe, so every write costs a syscall like it does on stdout
+	reader, output, err := os.Pipe()
+	if err != nil {
+		b.Fatal(err)
+	}
+	drained := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, reader)
+		reader.Close()
+		close(drained)
+	}()
+	defer func() {
+		output.Close()
+		<-drained
+	}()
+
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			b.Fatal(err)
+		}
+		cmd := command{
+			output:       output,
+			input:        file,
+			bytesPerLine: defaultCols,
+			groupSize:    defaultGroupSize,
+			maxBytes:     -1,
+		}
+		if err := cmd.run(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPrintLine(b *testing.B) {
+	line := []byte("a line of sixteen\x00\xff"[:16])
+	cmd := command{
+		output:       io.Discard,
+		bytesPerLine: defaultCols,
+		groupSize:    defaultGroupSize,
+	}
+
+	b.ReportAllocs()
+	for range b.N {
+		cmd.printLine(0, line)
+	}
+}
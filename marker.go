@@ -0,0 +1,50 @@
+package ccxxd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// findMarker returns the offset of the first --offset-from-marker byte between
+// startOffset and endOffset. The input is rewound to where it was, so the dump
+// that follows still sees every byte.
+func (cmd *command) findMarker() (int64, error) {
+	seeker, ok := cmd.input.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("--offset-from-marker needs a seekable input")
+	}
+	pos, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, fmt.Errorf("error getting offset: %v", err)
+	}
+	_, err = seeker.Seek(cmd.startOffset, io.SeekStart)
+	if err != nil {
+		return 0, fmt.Errorf("error setting offset: %v", err)
+	}
+
+	reader := bufio.NewReader(cmd.input)
+	found := int64(-1)
+	for offset := cmd.startOffset; offset < cmd.endOffset; offset++ {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if b == cmd.marker {
+			found = offset
+			break
+		}
+	}
+
+	_, err = seeker.Seek(pos, io.SeekStart)
+	if err != nil {
+		return 0, fmt.Errorf("error setting offset: %v", err)
+	}
+	if found < 0 {
+		return 0, fmt.Errorf("marker byte 0x%02x not found", cmd.marker)
+	}
+	return found, nil
+}
@@ -0,0 +1,45 @@
+package ccxxd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOffsetFromMarker(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("head\x7ebody and more"),
+		bytesPerLine: 4,
+		groupSize:    2,
+		maxBytes:     -1,
+		useMarker:    true,
+		marker:       0x7e,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `-0000004: 6865 6164  head
+00000000: 7e62 6f64  ~bod
+00000004: 7920 616e  y an
+00000008: 6420 6d6f  d mo
+0000000c: 7265       re
+`
+	assertEqual(t, out.String(), want)
+}
+
+func TestOffsetFromMarkerNotFound(t *testing.T) {
+	cmd := command{
+		output:       &bytes.Buffer{},
+		input:        strings.NewReader("no marker here"),
+		bytesPerLine: 4,
+		groupSize:    2,
+		maxBytes:     -1,
+		useMarker:    true,
+		marker:       0x7e,
+	}
+	err := cmd.run()
+	if err == nil || !strings.Contains(err.Error(), "marker byte 0x7e not found") {
+		t.Errorf("expected marker not found error, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package ccxxd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// multiFile reads several file arguments one after another as one input stream,
+// like cat file1 file2 | ccxxd, so offsets keep counting across files.
+type multiFile struct {
+	files  []*os.File
+	reader io.Reader
+}
+
+// openMultiFile opens every named file, closing the ones already open if one fails.
+func openMultiFile(names []string) (*multiFile, error) {
+	m := &multiFile{}
+	readers := make([]io.Reader, 0, len(names))
+	for _, name := range names {
+		file, err := os.Open(name)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("error opening %v as file: %v", name, err)
+		}
+		m.files = append(m.files, file)
+		readers = append(readers, file)
+	}
+	m.reader = io.MultiReader(readers...)
+	return m, nil
+}
+
+func (m *multiFile) Read(p []byte) (int, error) {
+	return m.reader.Read(p)
+}
+
+// size returns the combined size of all the files, or unknownLength if one of
+// them isn't a regular file and can't tell its size.
+func (m *multiFile) size() (int64, error) {
+	var total int64
+	for _, file := range m.files {
+		info, err := file.Stat()
+		if err != nil {
+			return 0, err
+		}
+		if !info.Mode().IsRegular() {
+			return unknownLength, nil
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
+// Close closes every file, reporting all errors.
+func (m *multiFile) Close() error {
+	var errs []error
+	for _, file := range m.files {
+		errs = append(errs, file.Close())
+	}
+	return errors.Join(errs...)
+}
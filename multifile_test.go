@@ -0,0 +1,46 @@
+package ccxxd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMultiFile(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	assertNoError(t, os.WriteFile(first, []byte("first file\n"), 0o644))
+	assertNoError(t, os.WriteFile(second, []byte("second\n"), 0o644))
+
+	input, err := openMultiFile([]string{first, second})
+	assertNoError(t, err)
+	defer input.Close()
+
+	size, err := input.size()
+	assertNoError(t, err)
+	if size != 18 {
+		t.Errorf("size = %d, want 18", size)
+	}
+
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        input,
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     -1,
+		startOffset:  4,
+	}
+	assertNoError(t, cmd.run())
+
+	// Captured from: cat first second | xxd -s 4
+	want := `00000004: 7420 6669 6c65 0a73 6563 6f6e 640a       t file.second.
+`
+	assertEqual(t, dump.String(), want)
+
+	if _, err := openMultiFile([]string{first, filepath.Join(dir, "missing")}); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
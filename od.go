@@ -0,0 +1,27 @@
+package ccxxd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// printODLine prints one line the way `od -A x -t x1z` does: a six digit hex
+// offset, every byte preceded by a single space, then the ascii panel in >text<.
+func (cmd *command) printODLine(offset int64, line []byte) {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%06x", cmd.shownOffset(offset))
+	for _, b := range line {
+		fmt.Fprintf(&builder, " %02x", b)
+	}
+	// Short lines are padded so the ascii panel stays in its column
+	builder.WriteString(strings.Repeat("   ", cmd.bytesPerLine-len(line)))
+	builder.WriteString("  >")
+	cmd.printASCII(line, &builder)
+	builder.WriteString("<\n")
+	fmt.Fprint(cmd.output, builder.String())
+}
+
+// printODFooter prints the offset just past the last byte, which od writes on a line of its own.
+func (cmd *command) printODFooter(offset int64) {
+	fmt.Fprintf(cmd.output, "%06x\n", cmd.shownOffset(offset))
+}
@@ -0,0 +1,108 @@
+package ccxxd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOD(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("Hello, world! This is od.\n\x01\x02"),
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     -1,
+		od:           true,
+	}
+	assertNoError(t, cmd.run())
+
+	// Captured from: od -A x -t x1z
+	want := "000000 48 65 6c 6c 6f 2c 20 77 6f 72 6c 64 21 20 54 68  >Hello, world! Th<\n" +
+		"000010 69 73 20 69 73 20 6f 64 2e 0a 01 02              >is is od....<\n" +
+		"00001c\n"
+	assertEqual(t, out.String(), want)
+}
+
+func TestODRepeats(t *testing.T) {
+	line := "000000 61 62 63 64 61 62 63 64 61 62 63 64 61 62 63 64  >abcdabcdabcdabcd<\n"
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+		want  string
+	}{
+		// Captured from: od -A x -t x1z
+		{
+			name:  "run in the middle",
+			args:  []string{"--od"},
+			input: strings.Repeat("abcd", 16) + "end",
+			want:  line + "*\n000040 65 6e 64                                         >end<\n000043\n",
+		},
+		{
+			name:  "a single repeat is a star too",
+			args:  []string{"--od"},
+			input: strings.Repeat("abcd", 8) + "end",
+			want:  line + "*\n000020 65 6e 64                                         >end<\n000023\n",
+		},
+		{
+			name:  "run at the end",
+			args:  []string{"--od"},
+			input: strings.Repeat("abcd", 12),
+			want:  line + "*\n000030\n",
+		},
+		{
+			name:  "--od-verbose like od -v",
+			args:  []string{"--od", "--od-verbose"},
+			input: strings.Repeat("abcd", 8),
+			want:  line + "000010" + line[6:] + "000020\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			code := runMain(tt.args, strings.NewReader(tt.input), &out, &errOut)
+			if code != exitOK {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+			}
+			assertEqual(t, out.String(), tt.want)
+
+			var reverted bytes.Buffer
+			assertNoError(t, revertToBinary(strings.NewReader(out.String()), &reverted, revertOptions{od: true}))
+			assertEqual(t, reverted.String(), tt.input)
+		})
+	}
+}
+
+func TestODRoundTrip(t *testing.T) {
+	original := "od round trip <with> brackets\x00\xff\n"
+
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        strings.NewReader(original),
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     -1,
+		od:           true,
+	}
+	assertNoError(t, cmd.run())
+
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(dump.String()), &reverted, cmd.revertOptions()))
+	assertEqual(t, reverted.String(), original)
+}
+
+func TestRevertODRepeats(t *testing.T) {
+	// od squeezes repeated lines into "*"
+	dump := `000000 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  >................<
+*
+000040 65 6e 64                                         >end<
+000043
+`
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(dump), &reverted, revertOptions{od: true}))
+	assertEqual(t, reverted.String(), strings.Repeat("\x00", 64)+"end")
+}
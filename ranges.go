@@ -0,0 +1,92 @@
+package ccxxd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// rangeSeparator is printed between the dumps of consecutive --ranges
+const rangeSeparator = "--"
+
+// byteRange is a <start>:<len> entry of a --ranges file
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+// loadRanges opens and parses a --ranges file.
+func loadRanges(name string) ([]byteRange, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v as file: %v", name, err)
+	}
+	defer file.Close()
+
+	return parseRanges(file)
+}
+
+// parseRanges reads one <start>:<len> range per line.
+// Blank lines and lines starting with '#' are skipped.
+func parseRanges(r io.Reader) ([]byteRange, error) {
+	var res []byteRange
+	scanner := bufio.NewScanner(r)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		startStr, lenStr, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: range %q should be <start>:<len>", lineNum, line)
+		}
+		start, err := strconv.ParseInt(strings.TrimSpace(startStr), 0, 64)
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("line %d: invalid start in range %q", lineNum, line)
+		}
+		length, err := strconv.ParseInt(strings.TrimSpace(lenStr), 0, 64)
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("line %d: invalid length in range %q", lineNum, line)
+		}
+		res = append(res, byteRange{start: start, length: length})
+	}
+	return res, scanner.Err()
+}
+
+// runRanges dumps each of cmd.ranges with its real offsets, separated by rangeSeparator.
+func (cmd *command) runRanges() error {
+	seeker, ok := cmd.input.(io.Seeker)
+	if !ok {
+		return fmt.Errorf("--ranges needs a seekable input")
+	}
+
+	// The attributes belong to the file, so they come once before the first range
+	if len(cmd.xattrs) > 0 {
+		err := cmd.printXattrs()
+		if err != nil {
+			return err
+		}
+		cmd.xattrs = nil
+	}
+
+	for i, r := range cmd.ranges {
+		if i > 0 {
+			fmt.Fprintln(cmd.output, rangeSeparator)
+		}
+		_, err := seeker.Seek(r.start, io.SeekStart)
+		if err != nil {
+			return fmt.Errorf("error setting offset: %v", err)
+		}
+		cmd.startOffset = r.start
+		cmd.maxBytes = r.length
+		err = cmd.run()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package ccxxd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunRanges(t *testing.T) {
+	ranges, err := parseRanges(strings.NewReader("# header and trailer\n2:3\n\n0x10:6\n"))
+	assertNoError(t, err)
+
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("abcdefghijklmnopqrstuvwxyz"),
+		bytesPerLine: 4,
+		groupSize:    2,
+		ranges:       ranges,
+	}
+	assertNoError(t, cmd.runRanges())
+
+	want := `00000002: 6364 65    cde
+--
+00000010: 7172 7374  qrst
+00000014: 7576       uv
+`
+	assertEqual(t, out.String(), want)
+}
+
+func TestRunRangesNotSeekable(t *testing.T) {
+	cmd := command{
+		output:       io.Discard,
+		input:        struct{ io.Reader }{strings.NewReader("abc")},
+		bytesPerLine: 4,
+		groupSize:    2,
+		ranges:       []byteRange{{start: 0, length: 2}},
+	}
+	if err := cmd.runRanges(); err == nil {
+		t.Errorf("expected error for non-seekable input")
+	}
+}
+
+func TestParseRangesInvalid(t *testing.T) {
+	for _, spec := range []string{"10", "a:2", "1:b", "-1:2"} {
+		if _, err := parseRanges(strings.NewReader(spec)); err == nil {
+			t.Errorf("expected error for range %q", spec)
+		}
+	}
+}
@@ -0,0 +1,1022 @@
+package ccxxd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LineError is a revert error caused by a specific line of the dump.
+type LineError struct {
+	Line int // 1-based line number in the dump
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// lineErrorf returns a LineError for line with a formatted message.
+func lineErrorf(line int, format string, args ...any) error {
+	return &LineError{Line: line, Err: fmt.Errorf(format, args...)}
+}
+
+// revertFormat is a hex dump layout that revertToBinary knows how to parse
+type revertFormat int
+
+const (
+	formatXxd      revertFormat = iota // offset, hex field and ascii panel, like xxd
+	formatPlain                        // continuous hex digits only, like xxd -p
+	formatIntelHex                     // Intel HEX records, ":LLAAAATT...CC"
+	formatBase64                       // standard base64
+	formatCSV                          // rows written by --csv
+	formatOD                           // od -A x -t x1z output, as written by --od
+)
+
+// revertOptions holds the dump layout hints given on the command line for -r
+type revertOptions struct {
+	csv          bool      // --csv input is csv rows written by --csv
+	csvDecimal   bool      // --csv-decimal csv offset and byte columns are decimal
+	od           bool      // --od input is od -A x -t x1z output
+	xorKey       []byte    // --xor-key xor the decoded bytes with a repeating key
+	prefix       []byte    // --prepend-hex raw bytes written before the reverted content
+	parity       bool      // --parity every line ends with a parity byte to verify and strip
+	padChar      byte      // --pad-char placeholder printed for missing bytes, 0 for none
+	decimal      bool      // -d offsets are decimal, detected from the dump by patchBinary if unset
+	littleEndian bool      // -e hex groups are little-endian, also set by a --self-describe header
+	groupSize    int       // -g bytes per hex group, also set by a --self-describe header
+	tolerant     bool      // --tolerant skip lines that fail to decode instead of stopping
+	check        bool      // --check collect every bad line and offset going back instead of stopping
+	warnings     io.Writer // Where --tolerant reports skipped lines, discarded if nil
+	seek         int64     // -seek shift the output by this many bytes before writing
+	zeroFill     bool      // --seek-zero-fill write zeros for -seek when the output can't seek
+	firstLine    int       // --dump-lines only revert dump lines firstLine..lastLine, 0 for all
+	lastLine     int       // Last line of the --dump-lines range
+	skipMarker   string    // --skip-marker line standing for repeats of the line before it, "*" if empty
+}
+
+var (
+	xxdLinePattern    = regexp.MustCompile(`^[0-9a-fA-F]+: `)
+	plainLinePattern  = regexp.MustCompile(`^[0-9a-fA-F\s]+$`)
+	ihexLinePattern   = regexp.MustCompile(`^:[0-9a-fA-F]+$`)
+	base64LinePattern = regexp.MustCompile(`^[A-Za-z0-9+/]+={0,2}$`)
+)
+
+// revertToBinary reads a hex dump and writes the decoded binary to output.
+// The dump format is detected from the first non-blank line unless set in opts.
+func revertToBinary(file io.Reader, output io.Writer, opts revertOptions) error {
+	if opts.seek > 0 {
+		err := seekOutput(output, opts.seek, opts.zeroFill)
+		if err != nil {
+			return err
+		}
+	}
+	// The prefix is written as is, it isn't part of the dump
+	if len(opts.prefix) > 0 {
+		_, err := output.Write(opts.prefix)
+		if err != nil {
+			return fmt.Errorf("error writing to stdout: %v", err)
+		}
+	}
+	if opts.firstLine > 0 {
+		var err error
+		file, err = selectLines(file, opts.firstLine, opts.lastLine)
+		if err != nil {
+			return err
+		}
+	}
+
+	input, format, err := sniffFormat(file)
+	if err != nil {
+		return err
+	}
+	if opts.csv {
+		format = formatCSV
+	}
+	if opts.od {
+		format = formatOD
+	}
+
+	// Formats with offsets xor each line by its offset, as the dump did. Plain hex
+	// and base64 have none, so their key is aligned to the output position.
+	if len(opts.xorKey) > 0 && (format == formatPlain || format == formatBase64) {
+		output = &xorWriter{writer: output, key: opts.xorKey}
+	}
+	writer := bufio.NewWriter(output)
+
+	switch format {
+	case formatCSV:
+		decimal := opts.csvDecimal
+		// A detected csv dump could be either base, unless given it comes from the rows
+		if !opts.csv && !decimal {
+			input, decimal, err = detectCSVDecimal(input)
+			if err != nil {
+				return err
+			}
+		}
+		err = revertCSV(input, writer, decimal, opts.xorKey)
+	case formatOD:
+		err = revertOD(input, writer, opts.xorKey)
+	case formatPlain:
+		err = revertPlain(input, writer)
+	case formatIntelHex:
+		err = revertIntelHex(input, writer, opts.xorKey)
+	case formatBase64:
+		err = revertBase64(input, writer)
+	default:
+		err = revertXxd(input, writer, opts)
+	}
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// seekOutput moves the output n bytes forward for -seek. Outputs that can't seek,
+// like a pipe, get n zero bytes instead if zeroFill is set and an error otherwise.
+func seekOutput(output io.Writer, n int64, zeroFill bool) error {
+	if seeker, ok := output.(io.Seeker); ok {
+		_, err := seeker.Seek(n, io.SeekCurrent)
+		if err == nil {
+			return nil
+		}
+	}
+	if !zeroFill {
+		return fmt.Errorf("-seek %d needs a seekable output, use --seek-zero-fill to write zeros instead", n)
+	}
+	zeros := make([]byte, min(n, 4096))
+	for n > 0 {
+		written, err := output.Write(zeros[:min(n, int64(len(zeros)))])
+		if err != nil {
+			return fmt.Errorf("error writing to stdout: %v", err)
+		}
+		n -= int64(written)
+	}
+	return nil
+}
+
+// sniffFormat reads up to the first non-blank line to detect the dump format.
+// Returns a reader that still yields the full input, including the consumed lines.
+func sniffFormat(file io.Reader) (io.Reader, revertFormat, error) {
+	reader := bufio.NewReader(file)
+	var consumed strings.Builder
+	format := formatXxd
+
+	for {
+		line, err := reader.ReadString('\n')
+		consumed.WriteString(line)
+		if strings.TrimSpace(line) != "" {
+			format = detectFormat(line)
+			break
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, format, err
+		}
+	}
+	return io.MultiReader(strings.NewReader(consumed.String()), reader), format, nil
+}
+
+// parseLineRange parses a --dump-lines range like "2-3" into its first and last line numbers.
+func parseLineRange(s string) (int, int, error) {
+	firstStr, lastStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("line range %q should be <first>-<last>", s)
+	}
+	first, err := strconv.Atoi(firstStr)
+	if err != nil || first < 1 {
+		return 0, 0, fmt.Errorf("invalid first line in range %q", s)
+	}
+	last, err := strconv.Atoi(lastStr)
+	if err != nil || last < first {
+		return 0, 0, fmt.Errorf("invalid last line in range %q", s)
+	}
+	return first, last, nil
+}
+
+// parsePadChar validates a --pad-char value, a single printable ascii char
+// that can't be mistaken for a hex digit or a separator.
+func parsePadChar(s string) (byte, error) {
+	if len(s) != 1 || !isValidASCII(s[0]) || s[0] == ' ' || isHexDigit(rune(s[0])) {
+		return 0, fmt.Errorf("invalid --pad-char %q, want a single printable non-hex char", s)
+	}
+	return s[0], nil
+}
+
+// selectLines returns a reader over lines first..last (1-based, inclusive) of file.
+// Reading stops after the last selected line.
+func selectLines(file io.Reader, first, last int) (io.Reader, error) {
+	scanner := bufio.NewScanner(file)
+	var selected strings.Builder
+
+	for lineNum := 1; lineNum <= last && scanner.Scan(); lineNum++ {
+		if lineNum >= first {
+			selected.WriteString(scanner.Text())
+			selected.WriteString("\n")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return strings.NewReader(selected.String()), nil
+}
+
+// detectFormat guesses the dump format from a single line.
+// Plain hex is preferred over base64 since every hex digit is also valid base64.
+func detectFormat(line string) revertFormat {
+	line = strings.TrimSpace(line)
+
+	switch {
+	case strings.HasPrefix(line, "offset,"):
+		return formatCSV
+	case xxdLinePattern.MatchString(line):
+		return formatXxd
+	case ihexLinePattern.MatchString(line):
+		return formatIntelHex
+	case plainLinePattern.MatchString(line):
+		return formatPlain
+	case base64LinePattern.MatchString(line):
+		return formatBase64
+	default:
+		return formatXxd
+	}
+}
+
+// revertXxd decodes a regular xxd style dump.
+// In tolerant mode lines that fail to decode are skipped with a warning, and bytes are
+// placed by their offsets so the skipped lines become zero filled gaps. The offset base
+// then matters, so unless opts.decimal is set it is detected with decimalOffsets.
+// A skip marker line written by -a or --squeeze stands for repeats of the line before it
+// up to the offset of the line after it.
+// With opts.check every bad line, and every offset going back before the end of the
+// line above it, is collected into the returned error instead of stopping the revert.
+func revertXxd(file io.Reader, writer *bufio.Writer, opts revertOptions) error {
+	if (opts.tolerant || opts.check) && !opts.decimal {
+		lines, err := readLines(file)
+		if err != nil {
+			return err
+		}
+		opts.decimal = decimalOffsets(lines)
+		file = strings.NewReader(strings.Join(lines, "\n"))
+	}
+	scanner := bufio.NewScanner(file)
+	out := offsetWriter{writer: writer, key: opts.xorKey}
+	var written int64   // Bytes written so far, where the key is at
+	var next int64      // Offset just past the previous line's bytes
+	var previous []byte // Bytes of the previous line, repeated after a skip marker
+	var problems []error
+	repeat := false
+	warnings := opts.warnings
+	if warnings == nil {
+		warnings = io.Discard
+	}
+	// write puts a line's bytes at offset, xored as the dump did. Outside tolerant mode
+	// lines are written back to back, so offsets in another base or shifted by -o
+	// don't move the key.
+	write := func(offset int64, data []byte) error {
+		if opts.tolerant {
+			return out.writeAt(offset, data)
+		}
+		if len(opts.xorKey) > 0 {
+			data = bytes.Clone(data)
+			xorBytes(data, opts.xorKey, written)
+		}
+		_, err := writer.Write(data)
+		if err != nil {
+			return fmt.Errorf("error writing to stdout: %v", err)
+		}
+		written += int64(len(data))
+		return nil
+	}
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if isSelfDescribeHeader(text) {
+			var err error
+			opts, err = parseSelfDescribeHeader(text, opts)
+			if err != nil {
+				return &LineError{Line: lineNum, Err: err}
+			}
+			continue
+		}
+		if isSkipMarker(text, opts.skipMarker) {
+			repeat = true
+			continue
+		}
+		// Comments like the --hash digest or --annotate names aren't part of the data
+		if isCommentLine(text) {
+			continue
+		}
+		var offset int64
+		var hexLine []byte
+		var err error
+		if plainLinePattern.MatchString(text) {
+			// No offset column, the line carries on where the last one ended
+			offset = next
+			hexLine, err = decodePlainLine(text)
+		} else {
+			offset, hexLine, err = decodeXxdLine(text, opts)
+			if err == nil && opts.littleEndian {
+				hexLine = reverseGroups(hexLine, opts.groupSize)
+			}
+		}
+		switch {
+		case err != nil:
+		case opts.check && offset < next && opts.decimal:
+			err = fmt.Errorf("offset %d jumps back before %d", offset, next)
+		case opts.check && offset < next:
+			err = fmt.Errorf("offset 0x%x jumps back before 0x%x", offset, next)
+		case opts.tolerant && offset < out.pos:
+			err = fmt.Errorf("offset 0x%x is before current output position 0x%x", offset, out.pos)
+		}
+		if err != nil {
+			switch {
+			case opts.check:
+				problems = append(problems, &LineError{Line: lineNum, Err: err})
+				// Later lines are still checked against where a decoded line ends
+				if hexLine != nil {
+					next = offset + int64(len(hexLine))
+				}
+			case !opts.tolerant:
+				return &LineError{Line: lineNum, Err: err}
+			default:
+				fmt.Fprintf(warnings, "warning: skipping line %d: %v\n", lineNum, err)
+			}
+			continue
+		}
+
+		if repeat {
+			for len(previous) > 0 && next+int64(len(previous)) <= offset {
+				err = write(next, previous)
+				if err != nil {
+					return err
+				}
+				next += int64(len(previous))
+			}
+			repeat = false
+		}
+		err = write(offset, hexLine)
+		if err != nil {
+			return err
+		}
+		next = offset + int64(len(hexLine))
+		if len(hexLine) > 0 {
+			previous = hexLine
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errors.Join(problems...)
+}
+
+// isCommentLine reports whether text is a "# " comment line printed along with the
+// dump, like the --hash digest or the --annotate and --struct lines, which -r skips.
+func isCommentLine(text string) bool {
+	return strings.HasPrefix(text, "# ")
+}
+
+// isSkipMarker reports whether text is the line -a and --squeeze print for skipped lines.
+// An empty marker means the default "*".
+func isSkipMarker(text, marker string) bool {
+	if marker == "" {
+		marker = "*"
+	}
+	return strings.TrimSpace(text) == marker
+}
+
+// patchBinary reads an xxd style dump and writes each line's bytes at its offset
+// in target, leaving every byte the dump doesn't cover untouched. Lines without an
+// offset column continue where the previous line ended. opts.seek shifts every line.
+// Unless opts.decimal is set, the offset base is detected with decimalOffsets.
+func patchBinary(file io.Reader, target io.WriterAt, opts revertOptions) error {
+	lines, err := readLines(file)
+	if err != nil {
+		return err
+	}
+	if !opts.decimal {
+		opts.decimal = decimalOffsets(lines)
+	}
+
+	var next int64      // Offset just past the previous line's bytes
+	var previous []byte // Bytes of the previous line, repeated after a skip marker
+	origin := int64(-1) // Offset of the first line written, where the key starts
+	repeat := false
+	// write puts a line's bytes at offset in target, xored as the dump did
+	write := func(offset int64, data []byte) error {
+		if origin < 0 {
+			origin = offset
+		}
+		if len(opts.xorKey) > 0 {
+			data = bytes.Clone(data)
+			xorBytes(data, opts.xorKey, offset-origin)
+		}
+		_, err := target.WriteAt(data, offset+opts.seek)
+		if err != nil {
+			return fmt.Errorf("error patching offset 0x%x: %v", offset+opts.seek, err)
+		}
+		return nil
+	}
+	for i, text := range lines {
+		lineNum := i + 1
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if isSelfDescribeHeader(text) {
+			var err error
+			opts, err = parseSelfDescribeHeader(text, opts)
+			if err != nil {
+				return &LineError{Line: lineNum, Err: err}
+			}
+			continue
+		}
+		if isSkipMarker(text, opts.skipMarker) {
+			repeat = true
+			continue
+		}
+		// Comments like the --hash digest or --annotate names aren't part of the data
+		if isCommentLine(text) {
+			continue
+		}
+
+		offset := next
+		var hexLine []byte
+		var err error
+		if plainLinePattern.MatchString(text) {
+			hexLine, err = decodePlainLine(text)
+		} else {
+			offset, hexLine, err = decodeXxdLine(text, opts)
+			if err == nil && opts.littleEndian {
+				hexLine = reverseGroups(hexLine, opts.groupSize)
+			}
+		}
+		if err != nil {
+			return &LineError{Line: lineNum, Err: err}
+		}
+
+		if repeat {
+			for len(previous) > 0 && next+int64(len(previous)) <= offset {
+				err = write(next, previous)
+				if err != nil {
+					return err
+				}
+				next += int64(len(previous))
+			}
+			repeat = false
+		}
+		err = write(offset, hexLine)
+		if err != nil {
+			return err
+		}
+		next = offset + int64(len(hexLine))
+		if len(hexLine) > 0 {
+			previous = hexLine
+		}
+	}
+	return nil
+}
+
+// readLines reads all lines of file, without their line endings.
+func readLines(file io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// decimalOffsets reports whether the offset column of an xxd style dump looks like it
+// was written with -d. A hex letter in any offset means hex; otherwise the offsets are
+// decimal if some line starts where the one before it ended only when read in base 10.
+// A dump with no such evidence is taken as hex.
+func decimalOffsets(lines []string) bool {
+	decimal := false
+	var prevHex, prevDec, prevLen int64 = 0, 0, -1
+	for _, text := range lines {
+		if !xxdLinePattern.MatchString(text) {
+			continue
+		}
+		offsetField, _, _ := strings.Cut(text, ":")
+		if strings.IndexFunc(offsetField, notDecimalDigit) >= 0 {
+			return false
+		}
+		hexOffset, _, err := parseXxdLine(text)
+		if err != nil {
+			continue
+		}
+		decOffset, data, err := parsePaddedXxdLine(text, 0, 10)
+		if err != nil {
+			continue
+		}
+		if prevLen >= 0 && prevDec+prevLen == decOffset && prevHex+prevLen != hexOffset {
+			decimal = true
+		}
+		prevHex, prevDec, prevLen = hexOffset, decOffset, int64(len(data))
+	}
+	return decimal
+}
+
+// notDecimalDigit reports whether r is anything but a decimal digit.
+func notDecimalDigit(r rune) bool {
+	return r < '0' || r > '9'
+}
+
+// decodeXxdLine parses an xxd style line into its offset and bytes.
+// With opts.parity set, the line's trailing parity byte is checked and stripped.
+// Cells filled with opts.padChar stand for absent bytes and are dropped.
+func decodeXxdLine(text string, opts revertOptions) (int64, []byte, error) {
+	base := 16
+	if opts.decimal {
+		base = 10
+	}
+	if !opts.parity {
+		return parsePaddedXxdLine(text, opts.padChar, base)
+	}
+	text, want, err := cutParity(text)
+	if err != nil {
+		return 0, nil, err
+	}
+	offset, hexLine, err := parsePaddedXxdLine(text, opts.padChar, base)
+	if err != nil {
+		return 0, nil, err
+	}
+	if parityByte(hexLine) != want {
+		return 0, nil, fmt.Errorf("parity mismatch, line has %02x but bytes give %02x", want, parityByte(hexLine))
+	}
+	return offset, hexLine, nil
+}
+
+// cutParity splits the "  xx" parity column written by --parity off the end of a line.
+func cutParity(text string) (string, byte, error) {
+	text = strings.TrimRight(text, "\r")
+	if len(text) < 4 || text[len(text)-4:len(text)-2] != "  " {
+		return "", 0, fmt.Errorf("missing parity byte")
+	}
+	b, err := hex.DecodeString(text[len(text)-2:])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid parity byte %q", text[len(text)-2:])
+	}
+	return text[:len(text)-4], b[0], nil
+}
+
+// parseXxdLine splits an xxd style line into its offset and decoded hex bytes.
+func parseXxdLine(text string) (int64, []byte, error) {
+	return parsePaddedXxdLine(text, 0, 16)
+}
+
+// parsePaddedXxdLine is parseXxdLine for dumps whose missing bytes are shown as padChar
+// placeholders in the hex field, a padChar of 0 means no placeholders.
+// The offset is parsed in the given base, 16 or 10 for -d dumps.
+func parsePaddedXxdLine(text string, padChar byte, base int) (int64, []byte, error) {
+	offsetField, rest, ok := strings.Cut(text, ":")
+	if !ok {
+		return 0, nil, fmt.Errorf("missing offset in %q", text)
+	}
+	field := strings.TrimSpace(offsetField)
+	offset, err := strconv.ParseInt(field, base, 64)
+	if err != nil && base == 10 && strings.IndexFunc(field, notHexDigit) < 0 && strings.IndexFunc(field, notDecimalDigit) >= 0 {
+		// Hex letters in an offset of an otherwise valid line, the dump wasn't made with -d
+		return 0, nil, fmt.Errorf("offset %q is hex, not decimal, drop -d", offsetField)
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid offset %q", offsetField)
+	}
+
+	rest = strings.TrimRight(rest, "\r")                     // Lines saved with CRLF endings
+	cleanLine := hexDigits(hexField(rest, padChar), padChar) // Remove spaces and placeholders from hex
+	if i := strings.IndexFunc(cleanLine, notHexDigit); i >= 0 {
+		return 0, nil, fmt.Errorf("hex field contains non-hex character %q", cleanLine[i])
+	}
+	hexLine, err := hex.DecodeString(cleanLine) // Decode hex to bytes
+	if err != nil {
+		return 0, nil, fmt.Errorf("error decoding string as hex: %v", err)
+	}
+	return offset, hexLine, nil
+}
+
+// hexField returns the hex part of an xxd line with the offset already removed.
+//
+// Padding (e.g. little-endian partial groups) can put double spaces inside the hex field,
+// so instead of splitting at the first "  " the ASCII boundary is found from the fact
+// that a line of n bytes ends with an n char ASCII panel, preceded by 2n hex digits.
+// Checking the longest possible panel first, the first n that fits is the real one, since
+// any longer panel would leave fewer than 2n hex digits.
+// Placeholders for missing bytes (padChar) are not counted as hex digits.
+// If no boundary follows a space, the ASCII panel was probably merged into the hex field
+// by reflowing, so the search is repeated without requiring one.
+// Each search is also tried with trailing whitespace trimmed, which editors add or keep
+// after the panel. The untrimmed line goes first, as a panel can end in real spaces.
+// Falls back to splitting at the first double space if no boundary fits.
+// An --ascii-width panel is shorter than the line's bytes, so groupedHexField reads those first.
+func hexField(rest string, padChar byte) string {
+	if field, ok := groupedHexField(rest, padChar); ok {
+		return field
+	}
+	for _, merged := range []bool{false, true} {
+		for _, line := range []string{rest, strings.TrimRight(rest, " \t")} {
+			// Count in runes, a --charset panel can hold multi-byte UTF-8 characters.
+			// starts[i] is where rune i starts, only needed if some rune isn't a single byte.
+			runeCount := utf8.RuneCountInString(line)
+			var starts []int
+			if runeCount != len(line) {
+				starts = make([]int, 0, runeCount+1)
+				for i := range line {
+					starts = append(starts, i)
+				}
+				starts = append(starts, len(line))
+			}
+			for n := runeCount / 3; n >= 0; n-- {
+				end := runeCount - n
+				if starts != nil {
+					end = starts[end]
+				}
+				field := line[:end]
+				if n > 0 && !merged && !strings.HasSuffix(field, " ") {
+					continue
+				}
+				digits := hexDigits(field, padChar)
+				if len(digits) != 2*n {
+					continue
+				}
+				if _, err := hex.DecodeString(digits); err == nil {
+					return field
+				}
+			}
+		}
+	}
+	return strings.Split(strings.TrimPrefix(rest, " "), "  ")[0]
+}
+
+// groupedHexField reads the hex field as runs of hex digits (or padChar) of one group's
+// length, single space separated with a shorter last one, ending at a double space.
+// It only answers for lines whose panel after that is shorter than their bytes, as
+// --ascii-width leaves it. Other lines are hexField's, whose search can tell a panel
+// from left padded -e groups or a reflowed line.
+func groupedHexField(rest string, padChar byte) (string, bool) {
+	line := strings.TrimPrefix(rest, " ")
+	end, groupLen := 0, 0
+	for {
+		n := strings.IndexFunc(line[end:], func(r rune) bool { return !isHexDigit(r) && r != rune(padChar) })
+		if n < 0 {
+			n = len(line) - end
+		}
+		if n == 0 || groupLen > 0 && n > groupLen {
+			return "", false
+		}
+		shorter := groupLen > 0 && n < groupLen
+		groupLen = max(groupLen, n)
+		end += n
+		if !strings.HasPrefix(line[end:], " ") {
+			return "", false
+		}
+		if shorter || strings.HasPrefix(line[end:], "  ") {
+			break
+		}
+		end++
+	}
+	// The panel may follow the padding of a short line, so its own leading spaces can't be told apart
+	panel := strings.TrimLeft(line[end:], " ")
+	bytesOnLine := len(hexDigits(line[:end], padChar)) / 2
+	if panel == "" || utf8.RuneCountInString(panel) >= bytesOnLine {
+		return "", false
+	}
+	return line[:end], true
+}
+
+// isHexDigit reports whether r is a hex digit, in either case.
+func isHexDigit(r rune) bool {
+	return strings.ContainsRune("0123456789abcdefABCDEF", r)
+}
+
+// notHexDigit reports whether r is anything but a hex digit, for strings.IndexFunc.
+func notHexDigit(r rune) bool {
+	return !isHexDigit(r)
+}
+
+// hexDigits removes spaces and any padChar placeholders from a hex field.
+func hexDigits(field string, padChar byte) string {
+	digits := strings.ReplaceAll(field, " ", "")
+	if padChar != 0 {
+		digits = strings.ReplaceAll(digits, string(padChar), "")
+	}
+	return digits
+}
+
+// checkDump validates a dump without writing anything, reading it the way -r
+// would with opts. All problems found in an xxd style dump are reported in the
+// returned error, see revertXxd, other formats stop at the first one.
+func checkDump(file io.Reader, opts revertOptions) error {
+	opts.check = true
+	// Where the output would start doesn't change what the dump holds
+	opts.seek = 0
+	return revertToBinary(file, io.Discard, opts)
+}
+
+// decodePlainLine decodes a line of hex digits only, whitespace is ignored.
+func decodePlainLine(text string) ([]byte, error) {
+	hexLine, err := hex.DecodeString(strings.Join(strings.Fields(text), ""))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding string as hex: %v", err)
+	}
+	return hexLine, nil
+}
+
+// revertPlain decodes lines made up of hex digits only, whitespace is ignored.
+// Lines that do have an offset column are decoded as xxd lines.
+func revertPlain(file io.Reader, writer *bufio.Writer) error {
+	scanner := bufio.NewScanner(file)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		var hexLine []byte
+		var err error
+		if xxdLinePattern.MatchString(scanner.Text()) {
+			// An xxd line among the plain ones, drop its offset and ascii
+			_, hexLine, err = parseXxdLine(scanner.Text())
+		} else {
+			hexLine, err = decodePlainLine(scanner.Text())
+		}
+		if err != nil {
+			return &LineError{Line: lineNum, Err: err}
+		}
+		_, err = writer.Write(hexLine)
+		if err != nil {
+			return fmt.Errorf("error writing to stdout: %v", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// revertBase64 decodes standard base64, newlines are ignored by the decoder.
+func revertBase64(file io.Reader, writer *bufio.Writer) error {
+	_, err := io.Copy(writer, base64.NewDecoder(base64.StdEncoding, file))
+	if err != nil {
+		return fmt.Errorf("error decoding base64: %v", err)
+	}
+	return nil
+}
+
+// revertIntelHex decodes Intel HEX records.
+// Addresses are relative to the first data record, gaps between records are zero filled.
+func revertIntelHex(file io.Reader, writer *bufio.Writer, key []byte) error {
+	scanner := bufio.NewScanner(file)
+	out := offsetWriter{writer: writer, key: key}
+	var base, origin int64
+	started := false
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, ":") {
+			return lineErrorf(lineNum, "intel hex record must start with ':'")
+		}
+		record, err := hex.DecodeString(line[1:])
+		if err != nil {
+			return lineErrorf(lineNum, "error decoding record as hex: %v", err)
+		}
+		// length, 2 address bytes, type and checksum
+		if len(record) < 5 || len(record) != int(record[0])+5 {
+			return lineErrorf(lineNum, "invalid intel hex record length")
+		}
+		var sum byte
+		for _, b := range record {
+			sum += b
+		}
+		if sum != 0 {
+			return lineErrorf(lineNum, "intel hex checksum mismatch")
+		}
+
+		address := int64(record[1])<<8 | int64(record[2])
+		data := record[4 : len(record)-1]
+
+		switch record[3] {
+		case 0x00: // data
+			if !started {
+				origin = base + address
+				started = true
+			}
+			err = out.writeAt(base+address-origin, data)
+			if err != nil {
+				return &LineError{Line: lineNum, Err: err}
+			}
+		case 0x01: // end of file
+			return nil
+		case 0x02: // extended segment address
+			if len(data) != 2 {
+				return lineErrorf(lineNum, "invalid extended segment address")
+			}
+			base = (int64(data[0])<<8 | int64(data[1])) << 4
+		case 0x04: // extended linear address
+			if len(data) != 2 {
+				return lineErrorf(lineNum, "invalid extended linear address")
+			}
+			base = (int64(data[0])<<8 | int64(data[1])) << 16
+		}
+		// start address records (0x03, 0x05) don't affect the output
+	}
+	return scanner.Err()
+}
+
+// detectCSVDecimal reads the rows written by --csv and reports whether they are
+// decimal, as written with --csv-decimal. Hex offsets are zero padded to 8 digits and
+// hex bytes always have 2, decimal ones are never padded. Rows that fit both bases
+// don't tell, if none tell or they disagree it's an error. The rows are returned
+// in a new reader for revertCSV.
+func detectCSVDecimal(file io.Reader) (io.Reader, bool, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, false, err
+	}
+	input := bytes.NewReader(data)
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	var hexRows, decimalRows bool
+	rows := 0
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			// Errors other than the end are revertCSV's to report, with their line
+			break
+		}
+		if record[0] == "offset" || len(record) < 2 {
+			continue
+		}
+		rows++
+		for i, field := range record[:len(record)-1] {
+			switch {
+			case field == "":
+			case strings.ContainsAny(field, "abcdefABCDEF"):
+				hexRows = true
+			case i == 0 && len(field) > 1 && field[0] == '0':
+				hexRows = true
+			case i == 0 && len(field) < 8, i > 0 && len(field) != 2:
+				decimalRows = true
+			}
+		}
+	}
+
+	switch {
+	case hexRows && decimalRows:
+		return nil, false, fmt.Errorf("csv dump mixes hex and decimal columns")
+	case !hexRows && !decimalRows && rows > 0:
+		return nil, false, fmt.Errorf("can't tell if the csv dump is hex or decimal, give --csv for hex or --csv-decimal")
+	}
+	return input, decimalRows, nil
+}
+
+// revertCSV decodes rows written by --csv, placing each row at its offset column.
+func revertCSV(file io.Reader, writer *bufio.Writer, decimal bool, key []byte) error {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	out := offsetWriter{writer: writer, key: key}
+
+	base := 16
+	if decimal {
+		base = 10
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading csv: %v", err)
+		}
+		// Skip the header
+		if record[0] == "offset" {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 {
+			return lineErrorf(line, "csv row needs offset and ascii columns")
+		}
+
+		offset, err := strconv.ParseInt(record[0], base, 64)
+		if err != nil {
+			return lineErrorf(line, "invalid offset %q", record[0])
+		}
+		var data []byte
+		for _, field := range record[1 : len(record)-1] {
+			if field == "" {
+				continue
+			}
+			b, err := strconv.ParseUint(field, base, 8)
+			if err != nil {
+				return lineErrorf(line, "invalid byte %q", field)
+			}
+			data = append(data, byte(b))
+		}
+		err = out.writeAt(offset, data)
+		if err != nil {
+			return &LineError{Line: line, Err: err}
+		}
+	}
+}
+
+// revertOD decodes `od -A x -t x1z` output. Each line is a hex offset followed by
+// space separated bytes and an optional >text< panel, the last line holds only the end offset.
+// A "*" line is od's marker for repeats of the previous line up to the next offset.
+func revertOD(file io.Reader, writer *bufio.Writer, key []byte) error {
+	scanner := bufio.NewScanner(file)
+	out := offsetWriter{writer: writer, key: key}
+	var previous []byte // Bytes of the last data line, repeated after a "*"
+	repeat := false
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		text, _, _ := strings.Cut(scanner.Text(), ">")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "*" {
+			repeat = true
+			continue
+		}
+
+		offset, err := strconv.ParseInt(fields[0], 16, 64)
+		if err != nil {
+			return lineErrorf(lineNum, "invalid offset %q", fields[0])
+		}
+		if repeat && len(previous) > 0 {
+			for out.pos+int64(len(previous)) <= offset {
+				err = out.writeAt(out.pos, previous)
+				if err != nil {
+					return &LineError{Line: lineNum, Err: err}
+				}
+			}
+		}
+		repeat = false
+
+		data, err := hex.DecodeString(strings.Join(fields[1:], ""))
+		if err != nil {
+			return lineErrorf(lineNum, "error decoding string as hex: %v", err)
+		}
+		err = out.writeAt(offset, data)
+		if err != nil {
+			return &LineError{Line: lineNum, Err: err}
+		}
+		if len(data) > 0 {
+			previous = data
+		}
+	}
+	return scanner.Err()
+}
+
+// offsetWriter writes chunks at given output positions, zero filling any gaps.
+// Positions must not go backwards.
+type offsetWriter struct {
+	writer  *bufio.Writer
+	pos     int64  // Number of bytes written so far
+	key     []byte // --xor-key applied to each chunk by its offset, the zero fill isn't xored
+	origin  int64  // Offset of the first chunk, where the key starts
+	started bool   // Whether a chunk was written and origin is set
+}
+
+// writeAt writes p at position off of the output.
+func (w *offsetWriter) writeAt(off int64, p []byte) error {
+	if off < w.pos {
+		return fmt.Errorf("offset 0x%x is before current output position 0x%x", off, w.pos)
+	}
+	for ; w.pos < off; w.pos++ {
+		if err := w.writer.WriteByte(0); err != nil {
+			return fmt.Errorf("error writing to stdout: %v", err)
+		}
+	}
+	if !w.started {
+		w.origin, w.started = off, true
+	}
+	if len(w.key) > 0 {
+		buf := make([]byte, len(p))
+		copy(buf, p)
+		xorBytes(buf, w.key, off-w.origin)
+		p = buf
+	}
+	n, err := w.writer.Write(p)
+	w.pos += int64(n)
+	if err != nil {
+		return fmt.Errorf("error writing to stdout: %v", err)
+	}
+	return nil
+}
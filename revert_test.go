@@ -0,0 +1,609 @@
+package ccxxd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRevertToBinary(t *testing.T) {
+	original := []byte("Hello, world!\n")
+	hexDump := "00000000: 4865 6c6c 6f2c 2077 6f72 6c64 210a       Hello, world!.\n"
+
+	input := strings.NewReader(hexDump)
+	var output bytes.Buffer
+
+	err := revertToBinary(input, &output, revertOptions{})
+	assertNoError(t, err)
+
+	got := output.Bytes()
+	if !bytes.Equal(got, original) {
+		t.Errorf("output does not match original\nGOT:  %q\nWANT: %q", got, original)
+	}
+}
+
+func TestRevertDetectsFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format revertFormat
+		input  string
+		want   []byte
+	}{
+		{
+			name:   "xxd dump",
+			format: formatXxd,
+			input:  "\n00000000: 4865 6c6c 6f0a                           Hello.\n",
+			want:   []byte("Hello\n"),
+		},
+		{
+			name:   "plain hex",
+			format: formatPlain,
+			input:  "48656c6c6f2c20\n776f726c64210a\n",
+			want:   []byte("Hello, world!\n"),
+		},
+		{
+			name:   "Intel HEX",
+			format: formatIntelHex,
+			input:  ":0400000048656C6C77\n:02000600210ACD\n:00000001FF\n",
+			want:   []byte("Hell\x00\x00!\n"),
+		},
+		{
+			name:   "base64",
+			format: formatBase64,
+			input:  "SGVsbG8s\nIHdvcmxkIQo=\n",
+			want:   []byte("Hello, world!\n"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, format, err := sniffFormat(strings.NewReader(tc.input))
+			assertNoError(t, err)
+			if format != tc.format {
+				t.Errorf("detected format %d, want %d", format, tc.format)
+			}
+
+			var output bytes.Buffer
+			err = revertToBinary(strings.NewReader(tc.input), &output, revertOptions{})
+			assertNoError(t, err)
+
+			if !bytes.Equal(output.Bytes(), tc.want) {
+				t.Errorf("GOT:  %q\nWANT: %q", output.Bytes(), tc.want)
+			}
+		})
+	}
+}
+
+func TestRevertIntelHexChecksum(t *testing.T) {
+	var output bytes.Buffer
+	err := revertToBinary(strings.NewReader(":0400000048656C6C76\n"), &output, revertOptions{})
+	if err == nil {
+		t.Errorf("expected checksum error")
+	}
+}
+
+func TestCheckDump(t *testing.T) {
+	valid := `00000000: 4865 6c6c 6f2c 2077 6f72 6c64 210a 4865  Hello, world!.He
+00000010: 6c6c 6f0a                                llo.
+`
+	assertNoError(t, checkDump(strings.NewReader(valid), revertOptions{}))
+
+	backwards := `00000000: 4865 6c6c 6f2c 2077 6f72 6c64 210a 4865  Hello, world!.He
+00000008: 6c6c 6f0a                                llo.
+`
+	err := checkDump(strings.NewReader(backwards), revertOptions{})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected backwards offset error on line 2, got %v", err)
+	}
+
+	badHex := "00000000: 48zz  H.\n"
+	if err := checkDump(strings.NewReader(badHex), revertOptions{}); err == nil {
+		t.Errorf("expected hex decoding error")
+	}
+
+	// Read like -r reads them, so every format it takes checks out
+	csvDump := "offset,b0,b1,b2,b3,ascii\n00000000,68,65,6c,6c,hell\n00000004,6f,,,,o\n"
+	assertNoError(t, checkDump(strings.NewReader(csvDump), revertOptions{}))
+	odDump := "000000 68 65 6c 6c 6f  >hello<\n000005\n"
+	assertNoError(t, checkDump(strings.NewReader(odDump), revertOptions{od: true}))
+	parityDump := "00000000: 6865 6c6c 6f  hello  63\n"
+	if err := checkDump(strings.NewReader(parityDump), revertOptions{parity: true}); err == nil || !strings.Contains(err.Error(), "parity mismatch") {
+		t.Errorf("expected a parity mismatch, got %v", err)
+	}
+}
+
+func TestRevertHexFieldBoundary(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []byte
+	}{
+		{
+			name:  "One byte little endian line",
+			input: "00000000:       41                             A\n",
+			want:  []byte("A"),
+		},
+		{
+			name:  "Partial group padding inside the hex field",
+			input: "00000000: 44434241       45   ABCDE\n",
+			want:  []byte("DCBAE"),
+		},
+		{
+			name:  "Hex-like ascii panel",
+			input: "00000000: 6361 6665                                cafe\n",
+			want:  []byte("cafe"),
+		},
+		{
+			name:  "Double space before ascii lost",
+			input: "00000000: 4865 6c6c 6f0a Hello.\n",
+			want:  []byte("Hello\n"),
+		},
+		{
+			name:  "Ascii merged into the hex field",
+			input: "00000000: 4865 6c6c 6f0aHello.\n",
+			want:  []byte("Hello\n"),
+		},
+		{
+			name:  "Uppercase hex merged with ascii",
+			input: "00000000: 4A4B 4C4DJKLM\n",
+			want:  []byte("JKLM"),
+		},
+		{
+			name:  "Hex-like --ascii-width panel",
+			input: "00000000: 3031 3233 3435 3637 3839 6162 6364 6566  0123\n",
+			want:  []byte("0123456789abcdef"),
+		},
+		{
+			name:  "--ascii-width panel with spaces",
+			input: "00000000: 6162 2063 6465 6667 6869 6a6b 6c6d 6e6f  ab c\n",
+			want:  []byte("ab cdefghijklmno"),
+		},
+		{
+			name:  "Short line with an --ascii-width panel",
+			input: "00000010: 7071 7273 7475 7677 7879                 pqrs\n",
+			want:  []byte("pqrstuvwxy"),
+		},
+		{
+			name:  "Multi-byte --charset latin1 panel",
+			input: "00000000: 4241 47db ca4b c9  BAGÛÊKÉ\n",
+			want:  []byte("BAG\xdb\xcaK\xc9"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := revertToBinary(strings.NewReader(tc.input), &output, revertOptions{})
+			assertNoError(t, err)
+
+			if !bytes.Equal(output.Bytes(), tc.want) {
+				t.Errorf("GOT:  %q\nWANT: %q", output.Bytes(), tc.want)
+			}
+		})
+	}
+}
+
+func TestRevertPrependHex(t *testing.T) {
+	hexDump := "00000000: 4869 0a                                  Hi.\n"
+
+	var output bytes.Buffer
+	err := revertToBinary(strings.NewReader(hexDump), &output, revertOptions{prefix: []byte{0xef, 0xbb, 0xbf}})
+	assertNoError(t, err)
+
+	want := []byte("\xef\xbb\xbfHi\n")
+	if !bytes.Equal(output.Bytes(), want) {
+		t.Errorf("GOT:  %q\nWANT: %q", output.Bytes(), want)
+	}
+}
+
+func TestRevertDumpLines(t *testing.T) {
+	hexDump := `00000000: 6162 6364  abcd
+00000004: 6566 6768  efgh
+00000008: 696a 6b6c  ijkl
+0000000c: 6d6e 6f70  mnop
+00000010: 7172       qr
+`
+	first, last, err := parseLineRange("2-3")
+	assertNoError(t, err)
+
+	var output bytes.Buffer
+	err = revertToBinary(strings.NewReader(hexDump), &output, revertOptions{firstLine: first, lastLine: last})
+	assertNoError(t, err)
+	assertEqual(t, output.String(), "efghijkl")
+
+	for _, s := range []string{"3", "0-2", "3-2", "a-b"} {
+		if _, _, err := parseLineRange(s); err == nil {
+			t.Errorf("expected error for range %q", s)
+		}
+	}
+}
+
+func TestParityRoundTrip(t *testing.T) {
+	original := "Parity checked\x00\xff dump"
+
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        strings.NewReader(original),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		parity:       true,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `00000000: 5061 7269 7479 2063  Parity c  64
+00000008: 6865 636b 6564 00ff  hecked..  fb
+00000010: 2064 756d 70          dump  2c
+`
+	assertEqual(t, dump.String(), want)
+
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(dump.String()), &reverted, cmd.revertOptions()))
+	assertEqual(t, reverted.String(), original)
+
+	// Flip one hex digit on the second line
+	corrupted := strings.Replace(dump.String(), "6865 636b", "6865 636c", 1)
+	err := revertToBinary(strings.NewReader(corrupted), &bytes.Buffer{}, cmd.revertOptions())
+	if err == nil || !strings.Contains(err.Error(), "line 2: parity mismatch") {
+		t.Errorf("expected parity mismatch on line 2, got %v", err)
+	}
+}
+
+func TestRevertTolerant(t *testing.T) {
+	hexDump := `00000000: 6162 6364  abcd
+00000004: 65zz 6768  e.gh
+00000008: 696a 6b6c  ijkl
+`
+	// Without --tolerant the corrupt line stops the revert
+	err := revertToBinary(strings.NewReader(hexDump), &bytes.Buffer{}, revertOptions{})
+	if err == nil {
+		t.Fatalf("expected error for corrupt line")
+	}
+
+	var output, warnings bytes.Buffer
+	err = revertToBinary(strings.NewReader(hexDump), &output, revertOptions{tolerant: true, warnings: &warnings})
+	assertNoError(t, err)
+
+	want := []byte("abcd\x00\x00\x00\x00ijkl")
+	if !bytes.Equal(output.Bytes(), want) {
+		t.Errorf("GOT:  %q\nWANT: %q", output.Bytes(), want)
+	}
+	if !strings.Contains(warnings.String(), "warning: skipping line 2") {
+		t.Errorf("expected warning about line 2, got %q", warnings.String())
+	}
+}
+
+func TestRevertPadChar(t *testing.T) {
+	// The last line fills its missing bytes with '.' placeholders
+	hexDump := `00000000: 5061 6464 6564 2064  Padded d
+00000008: 756d 70.. .... ....  ump
+`
+	var output bytes.Buffer
+	err := revertToBinary(strings.NewReader(hexDump), &output, revertOptions{padChar: '.'})
+	assertNoError(t, err)
+	assertEqual(t, output.String(), "Padded dump")
+
+	// Without the hint the placeholders aren't hex
+	err = revertToBinary(strings.NewReader(hexDump), &bytes.Buffer{}, revertOptions{})
+	if err == nil {
+		t.Errorf("expected error for placeholders without --pad-char")
+	}
+
+	for _, invalid := range []string{"", "..", "a", "0", " "} {
+		if _, err := parsePadChar(invalid); err == nil {
+			t.Errorf("parsePadChar(%q): expected error", invalid)
+		}
+	}
+}
+
+func TestRevertNonHexField(t *testing.T) {
+	// The hex field itself is damaged, no ASCII boundary can explain it
+	hexDump := "00000000: 48g5 6c6c  Hell\n"
+	err := revertToBinary(strings.NewReader(hexDump), &bytes.Buffer{}, revertOptions{})
+	if err == nil || !strings.Contains(err.Error(), "line 1: hex field contains non-hex character 'g'") {
+		t.Errorf("expected non-hex character error, got %v", err)
+	}
+}
+
+func TestRevertMissingOffsetColumn(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "prefixed lines",
+			input: "00000000: 6d69 7865 6420  mixed \n00000006: 6475 6d70       dump\n",
+		},
+		{
+			name:  "plain lines",
+			input: "6d69786564206475\n6d70\n",
+		},
+		{
+			name:  "plain line inside an xxd dump",
+			input: "00000000: 6d69 7865 6420  mixed \n6475 6d70\n",
+		},
+		{
+			name:  "xxd line inside a plain dump",
+			input: "6d69786564\n00000005: 2064 756d 70   dump\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+			assertNoError(t, revertToBinary(strings.NewReader(tc.input), &output, revertOptions{}))
+			assertEqual(t, output.String(), "mixed dump")
+		})
+	}
+}
+
+func TestRevertDoubleSpaceInASCII(t *testing.T) {
+	original := "a  b and c  d  \x00e  f"
+
+	for _, cols := range []int{4, 6, 16} {
+		var dump bytes.Buffer
+		cmd := command{
+			output:       &dump,
+			input:        strings.NewReader(original),
+			bytesPerLine: cols,
+			groupSize:    2,
+			maxBytes:     -1,
+		}
+		assertNoError(t, cmd.run())
+
+		var reverted bytes.Buffer
+		assertNoError(t, revertToBinary(strings.NewReader(dump.String()), &reverted, revertOptions{}))
+		if reverted.String() != original {
+			t.Errorf("-c %d: GOT %q WANT %q\ndump:\n%s", cols, reverted.String(), original, dump.String())
+		}
+	}
+
+	// Short last line, so the hex field ends in padding as well
+	hexDump := "00000000: 6120 2062 2020 2063  a  b   c\n00000008: 2020 64              d\n"
+	var output bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(hexDump), &output, revertOptions{}))
+	assertEqual(t, output.String(), "a  b   c  d")
+}
+
+func TestRevertLittleEndian(t *testing.T) {
+	original := "little-endian groups of four\x01\x02\x03"
+
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        strings.NewReader(original),
+		bytesPerLine: 16,
+		groupSize:    4,
+		maxBytes:     -1,
+		littleEndian: true,
+	}
+	assertNoError(t, cmd.run())
+
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(dump.String()), &reverted, cmd.revertOptions()))
+	assertEqual(t, reverted.String(), original)
+}
+
+// patchBuffer is an in-memory io.WriterAt that grows like a file would
+type patchBuffer []byte
+
+func (b *patchBuffer) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(*b) {
+		*b = append(*b, make([]byte, end-len(*b))...)
+	}
+	return copy((*b)[off:], p), nil
+}
+
+func TestPatchBinary(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "single line in the middle",
+			input: "00000004: 5858  XX\n",
+			want:  "abcdXXghijkl",
+		},
+		{
+			name:  "lines out of order",
+			input: "00000008: 5959  YY\n00000000: 5a    Z\n",
+			want:  "ZbcdefghYYkl",
+		},
+		{
+			name:  "plain line continues after the previous one",
+			input: "00000002: 5151  QQ\n5252\n",
+			want:  "abQQRRghijkl",
+		},
+		{
+			name:  "past the end grows the file",
+			input: "0000000e: 2121  !!\n",
+			want:  "abcdefghijkl\x00\x00!!",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target := patchBuffer("abcdefghijkl")
+			assertNoError(t, patchBinary(strings.NewReader(tc.input), &target, revertOptions{}))
+			assertEqual(t, string(target), tc.want)
+		})
+	}
+
+	target := patchBuffer("abcd")
+	err := patchBinary(strings.NewReader("00000000: 4141  AA\n00000002: 4zz1  ..\n"), &target, revertOptions{})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected error on line 2, got %v", err)
+	}
+}
+
+func TestPatchDecimalOffsets(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  revertOptions
+		want  string
+	}{
+		{
+			name:  "decimal detected from consecutive lines",
+			input: "00000008: 5858 5858  XXXX\n00000012: 5959 5959  YYYY\n",
+			want:  "abcdefghXXXXYYYYqrst",
+		},
+		{
+			name:  "hex letter means hex",
+			input: "0000000a: 5858  XX\n0000000c: 5959  YY\n",
+			want:  "abcdefghijXXYYopqrst",
+		},
+		{
+			name:  "single line is hex without -d",
+			input: "00000010: 5a5a  ZZ\n",
+			want:  "abcdefghijklmnopZZst",
+		},
+		{
+			name:  "single line with -d",
+			input: "00000010: 5a5a  ZZ\n",
+			opts:  revertOptions{decimal: true},
+			want:  "abcdefghijZZmnopqrst",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target := patchBuffer("abcdefghijklmnopqrst")
+			assertNoError(t, patchBinary(strings.NewReader(tc.input), &target, tc.opts))
+			assertEqual(t, string(target), tc.want)
+		})
+	}
+}
+
+func TestRevertSeek(t *testing.T) {
+	hexDump := "00000000: 4142  AB\n"
+
+	// A file seeks, leaving a zero filled hole
+	name := filepath.Join(t.TempDir(), "out")
+	file, err := os.Create(name)
+	assertNoError(t, err)
+	assertNoError(t, revertToBinary(strings.NewReader(hexDump), file, revertOptions{seek: 4}))
+	assertNoError(t, file.Close())
+	got, err := os.ReadFile(name)
+	assertNoError(t, err)
+	assertEqual(t, string(got), "\x00\x00\x00\x00AB")
+
+	// A buffer can't seek
+	err = revertToBinary(strings.NewReader(hexDump), &bytes.Buffer{}, revertOptions{seek: 4})
+	if err == nil || !strings.Contains(err.Error(), "--seek-zero-fill") {
+		t.Errorf("expected non-seekable output error, got %v", err)
+	}
+
+	var output bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(hexDump), &output, revertOptions{seek: 4, zeroFill: true}))
+	assertEqual(t, output.String(), "\x00\x00\x00\x00AB")
+
+	// In patch mode every line moves by the seek
+	target := patchBuffer("abcdefgh")
+	assertNoError(t, patchBinary(strings.NewReader("00000001: 5858  XX\n"), &target, revertOptions{seek: 4}))
+	assertEqual(t, string(target), "abcdeXXh")
+}
+
+func TestRevertCRLF(t *testing.T) {
+	original := "Windows line endings\r\nand  spaces  \x00\xff"
+	edits := []struct {
+		name   string
+		ending string
+	}{
+		{"crlf", "\r\n"},
+		{"trailing whitespace", "  \t\n"},
+		{"trailing whitespace and crlf", " \r\n"},
+	}
+
+	// The -e dump's short last line has padding inside the hex field, so the ASCII
+	// boundary can't be found by splitting at the first double space
+	for _, littleEndian := range []bool{false, true} {
+		var dump bytes.Buffer
+		cmd := command{
+			output:       &dump,
+			input:        strings.NewReader(original),
+			bytesPerLine: 16,
+			groupSize:    4,
+			littleEndian: littleEndian,
+			maxBytes:     -1,
+		}
+		assertNoError(t, cmd.run())
+		opts := revertOptions{littleEndian: littleEndian, groupSize: 4}
+
+		for _, edit := range edits {
+			t.Run(fmt.Sprintf("%s little-endian=%v", edit.name, littleEndian), func(t *testing.T) {
+				edited := strings.ReplaceAll(dump.String(), "\n", edit.ending)
+				var output bytes.Buffer
+				assertNoError(t, revertToBinary(strings.NewReader(edited), &output, opts))
+				assertEqual(t, output.String(), original)
+			})
+		}
+	}
+}
+
+func TestRevertOffsetBase(t *testing.T) {
+	original := "0123456789abcdefghijklmnopqrstuvwxyzABCD"
+	dump := func(decimal bool) string {
+		var out bytes.Buffer
+		cmd := command{
+			output:       &out,
+			input:        strings.NewReader(original),
+			bytesPerLine: 16,
+			groupSize:    2,
+			maxBytes:     -1,
+			decimal:      decimal,
+		}
+		assertNoError(t, cmd.run())
+		return out.String()
+	}
+	decimalDump, hexDump := dump(true), dump(false)
+	// The middle line of each fails to decode, --tolerant zero fills its 16 bytes
+	corrupt := func(dump string) string {
+		lines := strings.SplitAfter(dump, "\n")
+		lines[1] = strings.Replace(lines[1], "6768", "zz68", 1)
+		return strings.Join(lines, "")
+	}
+	withGap := original[:16] + strings.Repeat("\x00", 16) + original[32:]
+
+	tests := []struct {
+		name  string
+		input string
+		opts  revertOptions
+		want  string
+	}{
+		{name: "decimal offsets detected", input: decimalDump, opts: revertOptions{tolerant: true}, want: original},
+		{name: "decimal offsets with -d", input: corrupt(decimalDump), opts: revertOptions{tolerant: true, decimal: true}, want: withGap},
+		{name: "hex offsets", input: corrupt(hexDump), opts: revertOptions{tolerant: true}, want: withGap},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+			assertNoError(t, revertToBinary(strings.NewReader(tc.input), &output, tc.opts))
+			assertEqual(t, output.String(), tc.want)
+		})
+	}
+
+	// Offsets of only digits are read in the -d base, hex letters can't be decimal
+	var output bytes.Buffer
+	err := revertToBinary(strings.NewReader("0000001a: 4142  AB\n"), &output, revertOptions{decimal: true})
+	if err == nil || !strings.Contains(err.Error(), "line 1: offset \"0000001a\" is hex, not decimal, drop -d") {
+		t.Errorf("expected a drop -d error, got %v", err)
+	}
+
+	// --check reads offsets in the same base
+	assertNoError(t, checkDump(strings.NewReader(decimalDump), revertOptions{}))
+	assertNoError(t, checkDump(strings.NewReader(decimalDump), revertOptions{decimal: true}))
+	backwards := "00000000: 3031 3233 3435 3637 3839 6162 6364 6566  0123456789abcdef\n" +
+		"00000012: 6768                                     gh\n"
+	assertNoError(t, checkDump(strings.NewReader(backwards), revertOptions{}))
+	err = checkDump(strings.NewReader(backwards), revertOptions{decimal: true})
+	if err == nil || !strings.Contains(err.Error(), "line 2: offset 12 jumps back before 16") {
+		t.Errorf("expected a decimal jump back error, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package ccxxd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// selfDescribePrefix starts the --self-describe header line
+const selfDescribePrefix = "# ccxxd "
+
+// printSelfDescribeHeader writes the layout of the dump as a comment line, e.g.
+// "# ccxxd cols=16 group=2 endian=big", so -r can configure itself from it.
+func (cmd *command) printSelfDescribeHeader() {
+	endian := "big"
+	if cmd.littleEndian {
+		endian = "little"
+	}
+	fmt.Fprintf(cmd.output, "%scols=%d group=%d endian=%s\n", selfDescribePrefix, cmd.bytesPerLine, cmd.groupSize, endian)
+}
+
+// isSelfDescribeHeader reports whether a dump line is a --self-describe header.
+func isSelfDescribeHeader(text string) bool {
+	return strings.HasPrefix(text, selfDescribePrefix)
+}
+
+// parseSelfDescribeHeader applies the layout in a --self-describe header to opts.
+// Unknown keys are ignored so newer headers still revert.
+func parseSelfDescribeHeader(text string, opts revertOptions) (revertOptions, error) {
+	for _, field := range strings.Fields(strings.TrimPrefix(text, selfDescribePrefix)) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return opts, fmt.Errorf("invalid header field %q, want <key>=<value>", field)
+		}
+		switch key {
+		case "cols":
+			// Lines carry their own length, cols is informational
+			_, err := strconv.Atoi(value)
+			if err != nil {
+				return opts, fmt.Errorf("invalid cols %q in header", value)
+			}
+		case "group":
+			group, err := strconv.Atoi(value)
+			if err != nil || group < 1 {
+				return opts, fmt.Errorf("invalid group %q in header", value)
+			}
+			opts.groupSize = group
+		case "endian":
+			switch value {
+			case "big":
+				opts.littleEndian = false
+			case "little":
+				opts.littleEndian = true
+			default:
+				return opts, fmt.Errorf("invalid endian %q in header, want big or little", value)
+			}
+		}
+	}
+	if opts.littleEndian && opts.groupSize == 0 {
+		opts.groupSize = defaultGroupSizeLittleEndian
+	}
+	return opts, nil
+}
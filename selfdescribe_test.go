@@ -0,0 +1,46 @@
+package ccxxd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSelfDescribeRoundTrip(t *testing.T) {
+	original := "little-endian groups\x00\x01\x02"
+
+	var dump bytes.Buffer
+	cmd := command{
+		output:       &dump,
+		input:        strings.NewReader(original),
+		bytesPerLine: 8,
+		groupSize:    4,
+		maxBytes:     -1,
+		littleEndian: true,
+		selfDescribe: true,
+	}
+	assertNoError(t, cmd.run())
+
+	if !strings.HasPrefix(dump.String(), "# ccxxd cols=8 group=4 endian=little\n") {
+		t.Fatalf("missing self-describe header in %q", dump.String())
+	}
+
+	// No options given, the header alone says how to read the groups
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(dump.String()), &reverted, revertOptions{}))
+	assertEqual(t, reverted.String(), original)
+}
+
+func TestParseSelfDescribeHeader(t *testing.T) {
+	opts, err := parseSelfDescribeHeader("# ccxxd cols=4 group=2 endian=little future=1", revertOptions{})
+	assertNoError(t, err)
+	if !opts.littleEndian || opts.groupSize != 2 {
+		t.Errorf("got littleEndian=%v groupSize=%d, want true and 2", opts.littleEndian, opts.groupSize)
+	}
+
+	for _, header := range []string{"# ccxxd group=0", "# ccxxd endian=middle", "# ccxxd cols"} {
+		if _, err := parseSelfDescribeHeader(header, revertOptions{}); err == nil {
+			t.Errorf("parseSelfDescribeHeader(%q): expected error", header)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ccxxd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// splitWriter spreads output across numbered files, <prefix>.000, <prefix>.001, ...
+// starting a new file once maxLines lines were written to the current one.
+// A write holding several lines is split at line ends, so printers that write
+// many lines at once still get maxLines per file.
+type splitWriter struct {
+	prefix   string
+	maxLines int
+	lines    int // Lines written to the current file
+	index    int // Number of files opened so far
+	file     *os.File
+	writer   *bufio.Writer
+}
+
+func (s *splitWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// The next file is only opened once there's something to put in it
+		if s.file == nil || s.lines >= s.maxLines {
+			if err := s.rotate(); err != nil {
+				return written, err
+			}
+		}
+		// Up to and including the line end that fills the current file
+		chunk := p
+		for i, left := 0, s.maxLines-s.lines; i < len(p); i++ {
+			if p[i] == '\n' {
+				left--
+				if left == 0 {
+					chunk = p[:i+1]
+					break
+				}
+			}
+		}
+		n, err := s.writer.Write(chunk)
+		written += n
+		s.lines += bytes.Count(chunk[:n], []byte("\n"))
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// rotate flushes and closes the current file and opens the next one.
+func (s *splitWriter) rotate() error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s.%03d", s.prefix, s.index)
+	file, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("error creating output file %v: %v", name, err)
+	}
+	s.file = file
+	s.writer = bufio.NewWriter(file)
+	s.lines = 0
+	s.index++
+	return nil
+}
+
+// Close flushes and closes the current file, if any.
+func (s *splitWriter) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.writer.Flush()
+	closeErr := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
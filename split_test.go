@@ -0,0 +1,67 @@
+package ccxxd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitOutput(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "dump")
+
+	cmd := command{
+		input:        strings.NewReader("abcdefghijkl"),
+		bytesPerLine: 4,
+		groupSize:    2,
+		maxBytes:     -1,
+		splitLines:   2,
+		splitPrefix:  prefix,
+	}
+	assertNoError(t, cmd.run())
+
+	first, err := os.ReadFile(prefix + ".000")
+	assertNoError(t, err)
+	assertEqual(t, string(first), `00000000: 6162 6364  abcd
+00000004: 6566 6768  efgh
+`)
+
+	second, err := os.ReadFile(prefix + ".001")
+	assertNoError(t, err)
+	assertEqual(t, string(second), "00000008: 696a 6b6c  ijkl\n")
+
+	if _, err := os.Stat(prefix + ".002"); !os.IsNotExist(err) {
+		t.Errorf("expected only two output files, got %v", err)
+	}
+}
+
+func TestSplitOutputMultiLineWrites(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "dump")
+
+	// --stable writes all lines of a dump line in one go
+	cmd := command{
+		input:        strings.NewReader("abcdefghij"),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		stable:       true,
+		splitLines:   4,
+		splitPrefix:  prefix,
+	}
+	assertNoError(t, cmd.run())
+
+	want := []string{
+		"00000000: 61  a\n00000001: 62  b\n00000002: 63  c\n00000003: 64  d\n",
+		"00000004: 65  e\n00000005: 66  f\n00000006: 67  g\n00000007: 68  h\n",
+		"00000008: 69  i\n00000009: 6a  j\n",
+	}
+	for i, w := range want {
+		got, err := os.ReadFile(fmt.Sprintf("%s.%03d", prefix, i))
+		assertNoError(t, err)
+		assertEqual(t, string(got), w)
+	}
+	if _, err := os.Stat(prefix + ".003"); !os.IsNotExist(err) {
+		t.Errorf("expected only three output files, got %v", err)
+	}
+}
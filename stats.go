@@ -0,0 +1,46 @@
+package ccxxd
+
+import "fmt"
+
+// statsConflicts are the flags --stats can't be combined with, since they pick
+// another output or shape the dump lines that --stats doesn't print.
+var statsConflicts = []string{
+	"r", "i", "p", "ps", "csv", "html", "j", "json", "od", "find", "template", "stable",
+	"a", "squeeze", "group-lines", "min-lines", "annotate", "struct", "self-describe",
+	"ranges", "sym-diff", "compare-checksums", "split-output",
+}
+
+// byteStats holds the --stats counts for the dumped bytes.
+type byteStats struct {
+	total     int64 // Bytes read
+	printable int64 // Bytes shown as a character in the ascii panel, per --charset
+	zeros     int64 // 0x00 bytes
+}
+
+// countStats adds a line's bytes to cmd.counts instead of printing it.
+func (cmd *command) countStats(line []byte) {
+	cmd.counts.total += int64(len(line))
+	for _, b := range line {
+		if _, ok := cmd.panelChar(b); ok {
+			cmd.counts.printable++
+		}
+		if b == 0 {
+			cmd.counts.zeros++
+		}
+	}
+}
+
+// printStats prints the --stats summary once the input is read.
+func (cmd *command) printStats() {
+	counts := cmd.counts
+	percent := func(n int64) float64 {
+		if counts.total == 0 {
+			return 0
+		}
+		return 100 * float64(n) / float64(counts.total)
+	}
+	fmt.Fprintf(cmd.output, "bytes:         %d\n", counts.total)
+	fmt.Fprintf(cmd.output, "printable:     %d (%.1f%%)\n", counts.printable, percent(counts.printable))
+	fmt.Fprintf(cmd.output, "non-printable: %d (%.1f%%)\n", counts.total-counts.printable, percent(counts.total-counts.printable))
+	fmt.Fprintf(cmd.output, "zero bytes:    %d (%.1f%%)\n", counts.zeros, percent(counts.zeros))
+}
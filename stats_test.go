@@ -0,0 +1,65 @@
+package ccxxd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	// 5 printable, 3 zero and 2 other non-printable bytes
+	input := "Hi\x00\x00\xe9\n!\x00ok"
+
+	tests := []struct {
+		name string
+		cmd  command
+		want string
+	}{
+		{
+			name: "whole input",
+			cmd:  command{maxBytes: -1},
+			want: "bytes:         10\n" +
+				"printable:     5 (50.0%)\n" +
+				"non-printable: 5 (50.0%)\n" +
+				"zero bytes:    3 (30.0%)\n",
+		},
+		{
+			name: "-s and -l",
+			cmd:  command{startOffset: 2, maxBytes: 4},
+			want: "bytes:         4\n" +
+				"printable:     0 (0.0%)\n" +
+				"non-printable: 4 (100.0%)\n" +
+				"zero bytes:    2 (50.0%)\n",
+		},
+		{
+			name: "latin1 counts 0xe9 as printable",
+			cmd:  command{maxBytes: -1, charset: charsets["latin1"]},
+			want: "bytes:         10\n" +
+				"printable:     6 (60.0%)\n" +
+				"non-printable: 4 (40.0%)\n" +
+				"zero bytes:    3 (30.0%)\n",
+		},
+		{
+			name: "empty input",
+			cmd:  command{startOffset: 10, maxBytes: -1},
+			want: "bytes:         0\n" +
+				"printable:     0 (0.0%)\n" +
+				"non-printable: 0 (0.0%)\n" +
+				"zero bytes:    0 (0.0%)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := tt.cmd
+			cmd.output = &out
+			cmd.input = strings.NewReader(input)
+			cmd.bytesPerLine = 4
+			cmd.groupSize = 2
+			cmd.stats = true
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
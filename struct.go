@@ -0,0 +1,94 @@
+package ccxxd
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// structField is one field of a --struct record
+type structField struct {
+	name   string // Empty if the spec didn't name the field
+	size   int
+	decode func(b []byte) string
+}
+
+// structTypes maps the fixed size field types to their decoders
+var structTypes = map[string]structField{
+	"u8":    {size: 1, decode: func(b []byte) string { return strconv.FormatUint(uint64(b[0]), 10) }},
+	"u16le": {size: 2, decode: func(b []byte) string { return strconv.FormatUint(uint64(binary.LittleEndian.Uint16(b)), 10) }},
+	"u16be": {size: 2, decode: func(b []byte) string { return strconv.FormatUint(uint64(binary.BigEndian.Uint16(b)), 10) }},
+	"u32le": {size: 4, decode: func(b []byte) string { return strconv.FormatUint(uint64(binary.LittleEndian.Uint32(b)), 10) }},
+	"u32be": {size: 4, decode: func(b []byte) string { return strconv.FormatUint(uint64(binary.BigEndian.Uint32(b)), 10) }},
+	"u64le": {size: 8, decode: func(b []byte) string { return strconv.FormatUint(binary.LittleEndian.Uint64(b), 10) }},
+	"u64be": {size: 8, decode: func(b []byte) string { return strconv.FormatUint(binary.BigEndian.Uint64(b), 10) }},
+}
+
+// parseStruct parses a record spec like "magic:u16le,flags:u8,id:bytes:4".
+// Each field is [<name>:]<type>, where type is u8, u16le/be, u32le/be, u64le/be or bytes:<n>.
+func parseStruct(spec string) ([]structField, error) {
+	var res []structField
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, typ := "", item
+		if first, rest, ok := strings.Cut(item, ":"); ok && first != "bytes" {
+			name, typ = first, rest
+		}
+
+		if field, ok := structTypes[typ]; ok {
+			field.name = name
+			res = append(res, field)
+			continue
+		}
+		sizeStr, ok := strings.CutPrefix(typ, "bytes:")
+		if !ok {
+			return nil, fmt.Errorf("unknown type in struct field %q", item)
+		}
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid byte count in struct field %q", item)
+		}
+		res = append(res, structField{name: name, size: size, decode: hex.EncodeToString})
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("struct spec %q has no fields", spec)
+	}
+	return res, nil
+}
+
+// structSize returns the number of bytes in one record.
+func structSize(fields []structField) int {
+	size := 0
+	for _, f := range fields {
+		size += f.size
+	}
+	return size
+}
+
+// printStructFields prints the decoded fields of the record on the line as a comment
+// line below it, which -r skips. Fields cut off by the end of the input are left out.
+func (cmd *command) printStructFields(line []byte) {
+	var values []string
+	pos := 0
+
+	for _, f := range cmd.structFields {
+		if pos+f.size > len(line) {
+			break
+		}
+		value := f.decode(line[pos : pos+f.size])
+		if f.name != "" {
+			value = f.name + "=" + value
+		}
+		values = append(values, value)
+		pos += f.size
+	}
+	if len(values) > 0 {
+		fmt.Fprintf(cmd.output, "# %s\n", strings.Join(values, ", "))
+	}
+}
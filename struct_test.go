@@ -0,0 +1,56 @@
+package ccxxd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStruct(t *testing.T) {
+	fields, err := parseStruct("u16le,u8")
+	assertNoError(t, err)
+
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("\x01\x02\x03\xff\x00\x07\x10"),
+		bytesPerLine: structSize(fields),
+		groupSize:    2,
+		maxBytes:     -1,
+		structFields: fields,
+	}
+	assertNoError(t, cmd.run())
+
+	// The last record is cut off before its u8 field
+	want := `00000000: 0102 03  ...
+# 513, 3
+00000003: ff00 07  ...
+# 255, 7
+00000006: 10       .
+`
+	assertEqual(t, out.String(), want)
+
+	// -r skips the decoded field lines
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(out.String()), &reverted, revertOptions{}))
+	assertEqual(t, reverted.String(), "\x01\x02\x03\xff\x00\x07\x10")
+}
+
+func TestParseStruct(t *testing.T) {
+	fields, err := parseStruct("magic:u32be, len:u16be, id:bytes:3, bytes:1")
+	assertNoError(t, err)
+	if structSize(fields) != 10 {
+		t.Errorf("structSize: got %d, want 10", structSize(fields))
+	}
+
+	var out bytes.Buffer
+	cmd := command{output: &out, structFields: fields}
+	cmd.printStructFields([]byte("\x7fELF\x00\x10\xde\xad\xbe\xef"))
+	assertEqual(t, out.String(), "# magic=2135247942, len=16, id=deadbe, ef\n")
+
+	for _, spec := range []string{"", "u24le", "x:bytes:0", "bytes:n"} {
+		if _, err := parseStruct(spec); err == nil {
+			t.Errorf("parseStruct(%q): expected error", spec)
+		}
+	}
+}
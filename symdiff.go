@@ -0,0 +1,102 @@
+package ccxxd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// loadDump parses an xxd style dump into a map of absolute offset to byte.
+func loadDump(r io.Reader) (map[int64]byte, error) {
+	res := make(map[int64]byte)
+	scanner := bufio.NewScanner(r)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if strings.TrimSpace(scanner.Text()) == "" || isCommentLine(scanner.Text()) {
+			continue
+		}
+		offset, data, err := parseXxdLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		for i, b := range data {
+			res[offset+int64(i)] = b
+		}
+	}
+	return res, scanner.Err()
+}
+
+// printSymDiff compares the dump read from cmd.input with the dump in cmd.symDiffFile.
+// Only lines where the dumps differ are printed, as a "<" line from the input dump,
+// a ">" line from the other dump and a line marking differing bytes with "^^".
+// Bytes missing from one of the dumps are shown as "--".
+func (cmd *command) printSymDiff() error {
+	ours, err := loadDump(cmd.input)
+	if err != nil {
+		return fmt.Errorf("error parsing input dump: %v", err)
+	}
+
+	file, err := os.Open(cmd.symDiffFile)
+	if err != nil {
+		return fmt.Errorf("error opening %v as file: %v", cmd.symDiffFile, err)
+	}
+	defer file.Close()
+	theirs, err := loadDump(file)
+	if err != nil {
+		return fmt.Errorf("error parsing %v: %v", cmd.symDiffFile, err)
+	}
+
+	// Collect the start offsets of all lines holding a differing byte
+	cols := int64(cmd.bytesPerLine)
+	blocks := make(map[int64]bool)
+	for _, dump := range []map[int64]byte{ours, theirs} {
+		for off := range dump {
+			a, inOurs := ours[off]
+			b, inTheirs := theirs[off]
+			if inOurs != inTheirs || a != b {
+				blocks[off-off%cols] = true
+			}
+		}
+	}
+	starts := make([]int64, 0, len(blocks))
+	for start := range blocks {
+		starts = append(starts, start)
+	}
+	slices.Sort(starts)
+
+	for _, start := range starts {
+		var left, right, marks strings.Builder
+		for i := range cols {
+			a, inOurs := ours[start+i]
+			b, inTheirs := theirs[start+i]
+			writeDiffByte(&left, a, inOurs)
+			writeDiffByte(&right, b, inTheirs)
+			if inOurs != inTheirs || a != b {
+				marks.WriteString("^^")
+			} else {
+				marks.WriteString("  ")
+			}
+			if (i+1)%int64(cmd.groupSize) == 0 {
+				left.WriteString(" ")
+				right.WriteString(" ")
+				marks.WriteString(" ")
+			}
+		}
+		fmt.Fprintf(cmd.output, "< %08x: %s\n", start, strings.TrimRight(left.String(), " "))
+		fmt.Fprintf(cmd.output, "> %08x: %s\n", start, strings.TrimRight(right.String(), " "))
+		fmt.Fprintf(cmd.output, "%*s%s\n", offsetCharWidth+2, "", strings.TrimRight(marks.String(), " "))
+	}
+	return nil
+}
+
+// writeDiffByte writes b as two hex digits, or "--" if the byte is missing.
+func writeDiffByte(builder *strings.Builder, b byte, present bool) {
+	if present {
+		fmt.Fprintf(builder, "%02x", b)
+	} else {
+		builder.WriteString("--")
+	}
+}
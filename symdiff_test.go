@@ -0,0 +1,51 @@
+package ccxxd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSymDiff(t *testing.T) {
+	ours := `00000000: 4865 6c6c 6f2c 2077  Hello, w
+00000008: 6f72 6c64 210a       orld!.
+`
+	theirs := `00000000: 4865 6c6c 6f2c 2077  Hello, w
+00000008: 6f72 6c64 3f0a 0a    orld?..
+`
+	other := filepath.Join(t.TempDir(), "other.hex")
+	assertNoError(t, os.WriteFile(other, []byte(theirs), 0o644))
+
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader(ours),
+		bytesPerLine: 8,
+		groupSize:    2,
+		symDiffFile:  other,
+	}
+	assertNoError(t, cmd.printSymDiff())
+
+	want := `< 00000008: 6f72 6c64 210a ----
+> 00000008: 6f72 6c64 3f0a 0a--
+                      ^^   ^^
+`
+	assertEqual(t, out.String(), want)
+}
+
+func TestLoadDumpComments(t *testing.T) {
+	// --annotate names and the --hash digest are comment lines, not dump lines
+	dump := `# ccxxd cols=4 group=2 endian=big
+00000000: 5249 4646  RIFF
+# magic
+00000004: 0a         .
+# sha256: 2d7f0b6e1e52d8e8fbb1e1b2e23d5f1cfa1fa2a4e8e2b6f0f1c9b8b5ad6d59b2
+`
+	got, err := loadDump(strings.NewReader(dump))
+	assertNoError(t, err)
+	if len(got) != 5 || got[0] != 'R' || got[4] != '\n' {
+		t.Errorf("loadDump gave %v, want the 5 dumped bytes", got)
+	}
+}
@@ -0,0 +1,118 @@
+package ccxxd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+)
+
+// parseXorKey decodes the --xor-key hex string.
+func parseXorKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xor key %q: %v", s, err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("xor key can't be empty")
+	}
+	return key, nil
+}
+
+// xorBytes xors data in place with a repeating key, offset is where data starts
+// counted from the first dumped byte. The key starts over at every dump, so -r can
+// line it up from the first offset in the dump, whatever -s, -o or -d did to it.
+func xorBytes(data, key []byte, offset int64) {
+	keyLen := int64(len(key))
+	for i := range data {
+		data[i] ^= key[(offset+int64(i))%keyLen]
+	}
+}
+
+// xorWriter xors everything written through it with a repeating key.
+// It is for -r of formats without offsets, where the output position is the offset.
+type xorWriter struct {
+	writer io.Writer
+	key    []byte
+	pos    int64 // Number of bytes written so far, used to align the key
+}
+
+func (w *xorWriter) Write(p []byte) (int, error) {
+	buf := make([]byte, len(p))
+	copy(buf, p)
+	xorBytes(buf, w.key, w.pos)
+
+	n, err := w.writer.Write(buf)
+	w.pos += int64(n)
+	return n, err
+}
+
+// parseMask parses a --mask value like 0x0f, 017 or 15.
+func parseMask(s string) (byte, error) {
+	mask, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mask %q, want a byte value like 0x0f", s)
+	}
+	return byte(mask), nil
+}
+
+// maskBytes ands every byte of data with mask, in place.
+func maskBytes(data []byte, mask byte) {
+	for i := range data {
+		data[i] &= mask
+	}
+}
+
+// reverseBits returns b with its bit order reversed, so 0x01 becomes 0x80.
+func reverseBits(b byte) byte {
+	return bits.Reverse8(b)
+}
+
+// reverseBitsBytes reverses the bit order of every byte of data, in place.
+func reverseBitsBytes(data []byte) {
+	for i, b := range data {
+		data[i] = reverseBits(b)
+	}
+}
+
+// complement maps nucleotide codes to their complement, case is kept
+var complement = map[byte]byte{
+	'A': 'T', 'T': 'A', 'C': 'G', 'G': 'C', 'U': 'A', 'N': 'N',
+	'a': 't', 't': 'a', 'c': 'g', 'g': 'c', 'u': 'a', 'n': 'n',
+}
+
+// reverseComplement returns a reversed copy of data with nucleotide codes complemented.
+// Bytes that aren't nucleotide codes are kept as they are.
+func reverseComplement(data []byte) []byte {
+	res := make([]byte, len(data))
+	for i, b := range data {
+		if c, ok := complement[b]; ok {
+			b = c
+		}
+		res[len(data)-1-i] = b
+	}
+	return res
+}
+
+// reverseGroups returns a copy of data with the bytes of every group of size reversed,
+// the same order printLittleEndianHex shows them in.
+func reverseGroups(data []byte, size int) []byte {
+	res := make([]byte, len(data))
+	for start := 0; start < len(data); start += size {
+		end := min(start+size, len(data))
+		for i := start; i < end; i++ {
+			res[i] = data[end-1-(i-start)]
+		}
+	}
+	return res
+}
+
+// parityByte returns the xor of all bytes in data.
+func parityByte(data []byte) byte {
+	var res byte
+	for _, b := range data {
+		res ^= b
+	}
+	return res
+}
@@ -0,0 +1,196 @@
+package ccxxd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestXorKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		input string
+		want  string
+	}{
+		{
+			name:  "Single byte key",
+			key:   "20",
+			input: "Hello",
+			want:  "00000000: 6845 4c4c 4f                             hELLO\n",
+		},
+		{
+			name:  "Multi byte key",
+			key:   "0001ff",
+			input: "\x00\x00\x00\x41\x41",
+			want:  "00000000: 0001 ff41 40                             ...A@\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := parseXorKey(tc.key)
+			assertNoError(t, err)
+
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader(tc.input),
+				bytesPerLine: 16,
+				groupSize:    2,
+				maxBytes:     -1,
+				xorKey:       key,
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tc.want)
+
+			// Reverting with the same key gives back the original input
+			var reverted bytes.Buffer
+			err = revertToBinary(&out, &reverted, cmd.revertOptions())
+			assertNoError(t, err)
+			assertEqual(t, reverted.String(), tc.input)
+		})
+	}
+}
+
+func TestXorKeyRevertByOffset(t *testing.T) {
+	input := "Hello, xor round trip!"
+
+	// The dump keys from its first byte, -r from the first offset in the dump
+	tests := []struct {
+		name       string
+		dumpArgs   []string
+		revertArgs []string
+		want       string
+	}{
+		{name: "-s", dumpArgs: []string{"-s", "3", "-c", "8"}, want: input[3:]},
+		// --csv places rows at their offsets, zero filling the start
+		{name: "-s --csv", dumpArgs: []string{"-s", "3", "--csv", "-c", "8"}, revertArgs: []string{"--csv"}, want: "\x00\x00\x00" + input[3:]},
+		{name: "-o", dumpArgs: []string{"-o", "0x101", "-c", "8"}, want: input},
+		{name: "-o --tolerant", dumpArgs: []string{"-o", "3", "-c", "8"}, revertArgs: []string{"--tolerant"}, want: "\x00\x00\x00" + input},
+		{name: "-d reverted without -d", dumpArgs: []string{"-d", "-c", "5"}, want: input},
+		{name: "-o --od", dumpArgs: []string{"-o", "5", "--od", "-c", "8"}, revertArgs: []string{"--od"}, want: "\x00\x00\x00\x00\x00" + input},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dump, errOut bytes.Buffer
+			code := runMain(append(tt.dumpArgs, "--xor-key", "deadbeef"), strings.NewReader(input), &dump, &errOut)
+			if code != exitOK {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+			}
+
+			var reverted bytes.Buffer
+			code = runMain(append([]string{"-r", "--xor-key", "deadbeef"}, tt.revertArgs...), &dump, &reverted, &errOut)
+			if code != exitOK {
+				t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+			}
+			assertEqual(t, reverted.String(), tt.want)
+		})
+	}
+
+	// Gaps zero filled by --tolerant aren't xored
+	var reverted, errOut bytes.Buffer
+	dump := "00000000: 9fef  AB\n00000004: 9de9  CD\n"
+	code := runMain([]string{"-r", "--tolerant", "--xor-key", "dead"}, strings.NewReader(dump), &reverted, &errOut)
+	if code != exitOK {
+		t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+	}
+	assertEqual(t, reverted.String(), "AB\x00\x00CD")
+}
+
+func TestParseXorKeyInvalid(t *testing.T) {
+	for _, key := range []string{"", "xyz", "abc"} {
+		if _, err := parseXorKey(key); err == nil {
+			t.Errorf("expected error for key %q", key)
+		}
+	}
+}
+
+func TestBitReverse(t *testing.T) {
+	for b, want := range map[byte]byte{0x01: 0x80, 0x80: 0x01, 0x0f: 0xf0, 0x12: 0x48, 0xa5: 0xa5, 0x00: 0x00, 0xff: 0xff} {
+		if got := reverseBits(b); got != want {
+			t.Errorf("reverseBits(%#02x) = %#02x, want %#02x", b, got, want)
+		}
+	}
+
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("\x01\x80\x82\x42\x12"),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		bitReverse:   true,
+	}
+	assertNoError(t, cmd.run())
+	// 0x82, 0x42 and 0x12 reverse to "A", "B" and "H", the ascii panel follows the hex
+	assertEqual(t, out.String(), "00000000: 8001 4142 48         ..ABH\n")
+}
+
+func TestRevcomp(t *testing.T) {
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("AACGTTGCaNc\n"),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		revcomp:      true,
+	}
+	assertNoError(t, cmd.run())
+
+	// Hex stays in file order, only the ascii panel is reverse complemented
+	want := `00000000: 4141 4347 5454 4743  GCAACGTT
+00000008: 614e 630a            .gNt
+`
+	assertEqual(t, out.String(), want)
+}
+
+func TestMask(t *testing.T) {
+	mask, err := parseMask("0x0F")
+	assertNoError(t, err)
+
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("\x12\x34\xab\xcdAz"),
+		bytesPerLine: 8,
+		groupSize:    2,
+		maxBytes:     -1,
+		useMask:      true,
+		mask:         mask,
+	}
+	assertNoError(t, cmd.run())
+	assertEqual(t, out.String(), "00000000: 0204 0b0d 010a       ......\n")
+
+	for _, s := range []string{"0x100", "ff", ""} {
+		if _, err := parseMask(s); err == nil {
+			t.Errorf("expected error for mask %q", s)
+		}
+	}
+}
+
+func TestLittleEndianASCII(t *testing.T) {
+	dump := func(leASCII bool) string {
+		var out bytes.Buffer
+		cmd := command{
+			output:       &out,
+			input:        strings.NewReader("ABCDEFGHIJ"),
+			bytesPerLine: 8,
+			groupSize:    4,
+			littleEndian: true,
+			maxBytes:     -1,
+			leASCII:      leASCII,
+		}
+		assertNoError(t, cmd.run())
+		return out.String()
+	}
+
+	assertEqual(t, dump(false), `00000000: 44434241 48474645  ABCDEFGH
+00000008:     4a49           IJ
+`)
+	assertEqual(t, dump(true), `00000000: 44434241 48474645  DCBAHGFE
+00000008:     4a49           JI
+`)
+}
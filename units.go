@@ -0,0 +1,53 @@
+package ccxxd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// printUnits16Line prints a line as 16-bit code units, 4 hex digits each,
+// big-endian unless -e is set. The text panel decodes the units as UTF-16,
+// showing '.' (or the -ph char) for unprintable characters. An odd trailing byte is shown as 2 hex digits.
+func (cmd *command) printUnits16Line(offset int64, line []byte) {
+	var builder strings.Builder
+	cmd.writeShownOffset(&builder, offset)
+	builder.WriteString(": ")
+
+	units := make([]uint16, 0, len(line)/2)
+	for i := 0; i+1 < len(line); i += 2 {
+		var unit uint16
+		if cmd.littleEndian {
+			unit = uint16(line[i]) | uint16(line[i+1])<<8
+		} else {
+			unit = uint16(line[i])<<8 | uint16(line[i+1])
+		}
+		units = append(units, unit)
+		// Two hex bytes, so -u applies the same way as to the byte printers
+		fmt.Fprintf(&builder, cmd.hexFormat()+cmd.hexFormat()+" ", byte(unit>>8), byte(unit))
+	}
+	printed := len(units)
+	if len(line)%2 != 0 {
+		fmt.Fprintf(&builder, cmd.hexFormat()+"   ", line[len(line)-1])
+		printed++
+	}
+
+	// Pad missing units so the text panel lines up
+	for i := printed; i < (cmd.bytesPerLine+1)/2; i++ {
+		builder.WriteString("     ")
+	}
+	builder.WriteString(" ")
+
+	for _, r := range utf16.Decode(units) {
+		if unicode.IsPrint(r) && r != unicode.ReplacementChar {
+			builder.WriteRune(r)
+		} else {
+			builder.WriteByte(cmd.placeholderChar())
+		}
+	}
+	if len(line)%2 != 0 {
+		builder.WriteByte(cmd.placeholderChar())
+	}
+	fmt.Fprintln(cmd.output, builder.String())
+}
@@ -0,0 +1,56 @@
+package ccxxd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestUnits16(t *testing.T) {
+	tests := []struct {
+		name         string
+		littleEndian bool
+		uppercase    bool
+		input        string
+		want         string
+	}{
+		{
+			name:         "UTF-16LE text",
+			littleEndian: true,
+			input:        "H\x00i\x00\xac\x20\n\x00!\x00",
+			want: `00000000: 0048 0069 20ac 000a  Hi€.
+00000008: 0021                 !
+`,
+		},
+		{
+			name:  "Big endian units with odd trailing byte",
+			input: "\x00A\x00B\x00",
+			want:  "00000000: 0041 0042 00         AB.\n",
+		},
+		{
+			name:         "Upper case little endian units",
+			littleEndian: true,
+			uppercase:    true,
+			input:        "\xac\x20\xe9\x00\xfe",
+			want:         "00000000: 20AC 00E9 FE         €é.\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := command{
+				output:       &out,
+				input:        strings.NewReader(tc.input),
+				bytesPerLine: 8,
+				groupSize:    2,
+				littleEndian: tc.littleEndian,
+				uppercase:    tc.uppercase,
+				maxBytes:     -1,
+				units:        16,
+			}
+			assertNoError(t, cmd.run())
+			assertEqual(t, out.String(), tc.want)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package ccxxd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// xattr is one extended attribute of the input file, listed by --xattr
+type xattr struct {
+	name  string
+	value []byte
+}
+
+// printXattrs lists each extended attribute under a "# xattr <name>" heading
+// with its value hex dumped in the current layout, followed by a blank line.
+// Every line is a "# " comment, so -r reads the dump below them back unchanged.
+func (cmd *command) printXattrs() error {
+	for _, attr := range cmd.xattrs {
+		fmt.Fprintf(cmd.output, "# xattr %s\n", attr.name)
+		var value bytes.Buffer
+		valueDump := command{
+			output:       &value,
+			input:        bytes.NewReader(attr.value),
+			bytesPerLine: cmd.bytesPerLine,
+			groupSize:    cmd.groupSize,
+			groupSpaces:  cmd.groupSpaces,
+			littleEndian: cmd.littleEndian,
+			maxBytes:     -1,
+		}
+		err := valueDump.run()
+		if err != nil {
+			return fmt.Errorf("error dumping xattr %s: %v", attr.name, err)
+		}
+		for _, line := range strings.SplitAfter(value.String(), "\n") {
+			if line != "" {
+				fmt.Fprintf(cmd.output, "# %s", line)
+			}
+		}
+	}
+	fmt.Fprintln(cmd.output)
+	return nil
+}
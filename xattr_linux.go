@@ -0,0 +1,51 @@
+package ccxxd
+
+import (
+	"bytes"
+	"fmt"
+	"syscall"
+)
+
+// readXattrs returns the extended attributes of the file at path, in the order the kernel lists them.
+func readXattrs(path string) ([]xattr, error) {
+	size, err := syscall.Listxattr(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing xattrs of %v: %v", path, err)
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	names := make([]byte, size)
+	size, err = syscall.Listxattr(path, names)
+	if err != nil {
+		return nil, fmt.Errorf("error listing xattrs of %v: %v", path, err)
+	}
+
+	var attrs []xattr
+	// Names are NUL terminated, one after another
+	for _, name := range bytes.Split(names[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		value, err := getXattr(path, string(name))
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, xattr{name: string(name), value: value})
+	}
+	return attrs, nil
+}
+
+// getXattr reads the value of a single extended attribute.
+func getXattr(path, name string) ([]byte, error) {
+	size, err := syscall.Getxattr(path, name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error reading xattr %v of %v: %v", name, path, err)
+	}
+	value := make([]byte, size)
+	size, err = syscall.Getxattr(path, name, value)
+	if err != nil {
+		return nil, fmt.Errorf("error reading xattr %v of %v: %v", name, path, err)
+	}
+	return value[:size], nil
+}
@@ -0,0 +1,63 @@
+package ccxxd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestXattr(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tagged")
+	assertNoError(t, os.WriteFile(path, []byte("content"), 0o644))
+	err := syscall.Setxattr(path, "user.ccxxd.note", []byte("hello"), 0)
+	if err != nil {
+		t.Skipf("filesystem doesn't support user xattrs: %v", err)
+	}
+
+	attrs, err := readXattrs(path)
+	assertNoError(t, err)
+
+	var out bytes.Buffer
+	cmd := command{
+		output:       &out,
+		input:        strings.NewReader("content"),
+		bytesPerLine: 16,
+		groupSize:    2,
+		maxBytes:     -1,
+		xattrs:       attrs,
+	}
+	assertNoError(t, cmd.run())
+
+	want := `# xattr user.ccxxd.note
+# 00000000: 6865 6c6c 6f                             hello
+
+00000000: 636f 6e74 656e 74                        content
+`
+	assertEqual(t, out.String(), want)
+
+	// -r skips the attribute lines
+	var reverted bytes.Buffer
+	assertNoError(t, revertToBinary(strings.NewReader(out.String()), &reverted, revertOptions{}))
+	assertEqual(t, reverted.String(), "content")
+
+	// Listed once for the file, not for every range
+	ranges := filepath.Join(t.TempDir(), "ranges")
+	assertNoError(t, os.WriteFile(ranges, []byte("0:2\n4:3\n"), 0o644))
+	out.Reset()
+	var errOut bytes.Buffer
+	code := runMain([]string{"--xattr", "--ranges", ranges, path}, nil, &out, &errOut)
+	if code != exitOK {
+		t.Fatalf("exit code %d, want %d, stderr: %q", code, exitOK, errOut.String())
+	}
+	want = `# xattr user.ccxxd.note
+# 00000000: 6865 6c6c 6f                             hello
+
+00000000: 636f                                     co
+--
+00000004: 656e 74                                  ent
+`
+	assertEqual(t, out.String(), want)
+}
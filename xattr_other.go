@@ -0,0 +1,13 @@
+//go:build !linux
+
+package ccxxd
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// readXattrs is only implemented on linux.
+func readXattrs(path string) ([]xattr, error) {
+	return nil, fmt.Errorf("--xattr is not supported on %v", runtime.GOOS)
+}